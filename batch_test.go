@@ -0,0 +1,209 @@
+package leveldb_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/cgo"
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+	"github.com/kezhuw/go-leveldb-benchmarks/kezhuw"
+	"github.com/kezhuw/go-leveldb-benchmarks/syndtr"
+)
+
+// BenchmarkReplicateBatch encodes batches of -batch_count random entries to
+// LevelDB representation, decodes them into fresh batches and writes them,
+// as replication of batches between processes does.
+func BenchmarkReplicateBatch(b *testing.B) {
+	opts := createOptions
+	opts.ReplayableBatches = true
+	db, cleanup := openEmptyDBWithOptions(b, &opts)
+	defer cleanup()
+	g := newFullRandomEntryGenerator(0, b.N)
+	n := maxInt(*batchCount, 1)
+	src, dst := db.Batch(), db.Batch()
+	var data []byte
+	replicate := func() {
+		var err error
+		if data, err = driver.EncodeBatch(data[:0], src); err != nil {
+			b.Fatalf("encode batch error: %s", err)
+		}
+		countBatchBytes(len(data))
+		dst.Clear()
+		if err := driver.DecodeBatch(data, dst); err != nil {
+			b.Fatalf("decode batch error: %s", err)
+		}
+		if err := db.Write(dst, &writeOptions); err != nil {
+			b.Fatalf("db write error: %s", err)
+		}
+		src.Clear()
+	}
+	resetBenchmark(b)
+	for i := 0; i < b.N; i++ {
+		src.Put(g.Key(i), g.Value(i))
+		if src.Len() >= n {
+			replicate()
+		}
+	}
+	if src.Len() != 0 {
+		replicate()
+	}
+}
+
+var writeBatchCounts = []int{1, 10, 100, 1000, 10000}
+var writeBatchBytes = []int{4 << 10, 64 << 10, 1 << 20}
+
+// buildWriteBatch builds benchmark writing random entries in batches, a batch
+// is written once full returns true.
+func buildWriteBatch(sync bool, full func(driver.Batch) bool) func(*testing.B) {
+	return func(b *testing.B) {
+		db, cleanup := openEmptyDB(b)
+		defer cleanup()
+		g := newFullRandomEntryGenerator(0, b.N)
+		opts := driver.WriteOptions{Sync: sync}
+		batch := db.Batch()
+		write := func() {
+			countBatchBytes(batch.ByteSize())
+			if err := db.Write(batch, &opts); err != nil {
+				b.Fatalf("db write error: %s", err)
+			}
+			batch.Clear()
+		}
+		var bytes int64
+		resetBenchmark(b)
+		for i := 0; i < b.N; i++ {
+			key, value := g.Key(i), g.Value(i)
+			bytes += int64(len(key) + len(value))
+			batch.Put(key, value)
+			if full(batch) {
+				write()
+			}
+		}
+		if batch.Len() != 0 {
+			write()
+		}
+		b.StopTimer()
+		if seconds := b.Elapsed().Seconds(); seconds > 0 {
+			reportMetric(b, float64(b.N)/seconds, "entries/s")
+			reportMetric(b, float64(bytes)/1e6/seconds, "MB/s")
+		}
+	}
+}
+
+// BenchmarkWriteBatch writes random entries in batches of fixed counts and of
+// fixed byte sizes, with and without sync.
+func BenchmarkWriteBatch(b *testing.B) {
+	for _, sync := range []bool{false, true} {
+		b.Run(fmt.Sprintf("sync-%t", sync), func(b *testing.B) {
+			for _, n := range writeBatchCounts {
+				n := n
+				b.Run(fmt.Sprintf("count-%d", n), buildWriteBatch(sync, func(batch driver.Batch) bool {
+					return batch.Len() >= n
+				}))
+			}
+			for _, n := range writeBatchBytes {
+				n := n
+				b.Run(fmt.Sprintf("bytes-%d", n), buildWriteBatch(sync, func(batch driver.Batch) bool {
+					return batch.ByteSize() >= n
+				}))
+			}
+		})
+	}
+}
+
+var batchDrivers = []struct {
+	name     string
+	newBatch func() driver.Batch
+}{
+	{"cgo", (&cgo.DB{}).Batch},
+	{"kezhuw", newReplayableKezhuwBatch},
+	{"syndtr", (&syndtr.DB{}).Batch},
+}
+
+// newReplayableKezhuwBatch returns batch of kezhuw db in memory opened with
+// option ReplayableBatches.
+func newReplayableKezhuwBatch() driver.Batch {
+	opts := driver.Options{
+		Storage:           driver.MemoryStorage,
+		CreateIfMissing:   true,
+		ReplayableBatches: true,
+	}
+	db, err := driver.Open("kezhuw", "batch-encoding-test", &opts)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+	return db.Batch()
+}
+
+func fillBatch(b driver.Batch) {
+	b.Put([]byte("stale"), []byte("cleared"))
+	b.Clear()
+	b.Put([]byte("a"), []byte("1"))
+	b.Delete([]byte("b"))
+	b.Put([]byte("c"), bytes.Repeat([]byte("v"), 300))
+	b.Put([]byte("d"), nil)
+}
+
+func TestBatchEncodingAcrossDrivers(t *testing.T) {
+	var want []byte
+	for _, src := range batchDrivers {
+		b := src.newBatch()
+		fillBatch(b)
+		data, err := driver.EncodeBatch(nil, b)
+		if err != nil {
+			t.Fatalf("%s: encode error: %s", src.name, err)
+		}
+		if b.Len() != 4 || b.ByteSize() != len(data) {
+			t.Errorf("%s: got Len %d and ByteSize %d, want 4 and %d", src.name, b.Len(), b.ByteSize(), len(data))
+		}
+		if want == nil {
+			want = data
+		} else if !bytes.Equal(data, want) {
+			t.Errorf("%s: encoding differs from %s", src.name, batchDrivers[0].name)
+		}
+		for _, dst := range batchDrivers {
+			d := dst.newBatch()
+			if err := driver.DecodeBatch(data, d); err != nil {
+				t.Fatalf("%s to %s: decode error: %s", src.name, dst.name, err)
+			}
+			got, err := driver.EncodeBatch(nil, d)
+			if err != nil {
+				t.Fatalf("%s to %s: encode error: %s", src.name, dst.name, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("%s to %s: round trip changed batch", src.name, dst.name)
+			}
+		}
+	}
+}
+
+func TestEncodeBatchNotReplayable(t *testing.T) {
+	b, want := (&kezhuw.DB{}).Batch(), newReplayableKezhuwBatch()
+	fillBatch(b)
+	fillBatch(want)
+	if b.Len() != want.Len() || b.ByteSize() != want.ByteSize() {
+		t.Errorf("got Len %d and ByteSize %d, want %d and %d", b.Len(), b.ByteSize(), want.Len(), want.ByteSize())
+	}
+	if _, err := driver.EncodeBatch(nil, b); err == nil {
+		t.Error("encode batch of db opened without ReplayableBatches: got no error")
+	}
+}
+
+func TestDecodeCorruptedBatch(t *testing.T) {
+	b := batchDrivers[1].newBatch()
+	fillBatch(b)
+	data, err := driver.EncodeBatch(nil, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dst := range batchDrivers {
+		d := dst.newBatch()
+		if err := driver.DecodeBatch(data[:len(data)-1], d); err != driver.ErrBatchCorrupt {
+			t.Errorf("%s: got error %v, want %v", dst.name, err, driver.ErrBatchCorrupt)
+		}
+		if d.Len() != 0 {
+			t.Errorf("%s: got %d updates from corrupted batch", dst.name, d.Len())
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"math"
 	"math/rand"
 	"os"
 	"runtime"
@@ -31,40 +30,40 @@ var batchCount = flag.Int("batch_count", 1, "Batch count per write")
 var writeBufferSize = flag.Int("write_buffer_size", 0, "Write buffer size")
 var bloomBits = flag.Int("bloom_bits", 0, "Bits per key for bloom filter")
 var openFiles = flag.Int("open_files", 0, "Max number of open files")
+var blockSize = flag.Int("block_size", 0, "Approximate size of user data packed per block")
+var blockRestartInterval = flag.Int("block_restart_interval", 0, "Number of keys between restart points for delta encoding of keys")
+var maxFileSize = flag.Int("max_file_size", 0, "Max size of table files")
+var paranoidChecks = flag.Bool("paranoid_checks", false, "Check data aggressively")
+var reuseLogs = flag.Bool("reuse_logs", false, "Reuse existing log and manifest files when opening db")
 
 var compression = flag.String("compression", "default", "")
 var compressionRatio = flag.Float64("compression_ratio", 0.5, "")
 
 var maxConcurrency = flag.Int("max_concurrency", 2048, "Max concurrency in concurrent benchmark")
 
+var seed = flag.Int64("seed", 0, "Seed for random keys and values, 0 to use current time unless -fixture_dir is set")
+
 var openOptions driver.Options
 var createOptions driver.Options
 
 var readOptions driver.ReadOptions
 var writeOptions driver.WriteOptions
 
-const hitKeyFormat = "%016d+"
-const missingKeyFormat = "%016d-"
-
-var keyLen int
+var randSeed int64
 
 var templateDBDir string
-
-func init() {
-	var b bytes.Buffer
-	keyLen, _ = fmt.Fprintf(&b, hitKeyFormat, math.MaxInt32)
-	b.Reset()
-	missingKeyLen, _ := fmt.Fprintf(&b, missingKeyFormat, math.MaxInt32)
-	if keyLen != missingKeyLen {
-		panic("len(key) != len(missingKey)")
-	}
-}
+var templateDBEntries int
 
 func initOptions() {
 	openOptions.MaxOpenFiles = *openFiles
 	openOptions.BlockCacheCapacity = *cacheSize
 	openOptions.WriteBufferSize = *writeBufferSize
 	openOptions.BloomBitsPerKey = *bloomBits
+	openOptions.BlockSize = *blockSize
+	openOptions.BlockRestartInterval = *blockRestartInterval
+	openOptions.MaxFileSize = *maxFileSize
+	openOptions.ParanoidChecks = *paranoidChecks
+	openOptions.ReuseLogs = *reuseLogs
 	switch *compression {
 	case "none":
 		openOptions.Compression = driver.NoCompression
@@ -79,6 +78,15 @@ func initOptions() {
 	createOptions.ErrorIfExists = true
 
 	writeOptions.Sync = *writeSync
+
+	randSeed = *seed
+	if randSeed == 0 && *fixtureDir == "" {
+		randSeed = time.Now().Unix()
+	}
+}
+
+func newRand() *rand.Rand {
+	return rand.New(rand.NewSource(randSeed))
 }
 
 func randomBytes(r *rand.Rand, n int) []byte {
@@ -100,22 +108,63 @@ func compressibleBytes(r *rand.Rand, ratio float64, n int) []byte {
 }
 
 type randomValueGenerator struct {
-	b []byte
-	k int
+	b      []byte
+	stride int
+	size   int
+	sizes  []int
 }
 
 func (g *randomValueGenerator) Value(i int) []byte {
-	i = (i * g.k) % len(g.b)
-	return g.b[i : i+g.k]
+	n := g.size
+	if g.sizes != nil {
+		n = g.sizes[i%len(g.sizes)]
+	}
+	i = (i * g.stride) % (len(g.b) - n + 1)
+	return g.b[i : i+n]
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// newRandomValueGenerator makes generator of values sliced from compressible
+// bytes. Values start at multiples of a random stride, which is not aligned
+// to meanSize, and buffer holds two values of maxSize besides stride, so
+// consecutive values differ even if they are large.
+func newRandomValueGenerator(r *rand.Rand, ratio float64, meanSize, maxSize int, sizes []int) randomValueGenerator {
+	n := maxInt(meanSize, 1)
+	stride := n/2 + 1 + r.Intn(n-n/2)
+	size := maxInt(2*maxSize, 1024*1024) + stride
+	b := make([]byte, 0, size+n)
+	for len(b) < size {
+		b = append(b, compressibleBytes(r, ratio, n)...)
+	}
+	if sizes == nil {
+		// Offsets of values run through whole buffer before repeating.
+		for gcd(stride, len(b)-meanSize+1) != 1 {
+			stride++
+		}
+	}
+	return randomValueGenerator{b: b, stride: stride, size: meanSize, sizes: sizes}
 }
 
 func makeRandomValueGenerator(r *rand.Rand, ratio float64, valueSize int) randomValueGenerator {
-	b := compressibleBytes(r, ratio, valueSize)
-	max := maxInt(valueSize, 1024*1024)
-	for len(b) < max {
-		b = append(b, compressibleBytes(r, ratio, valueSize)...)
+	return newRandomValueGenerator(r, ratio, valueSize, valueSize, nil)
+}
+
+// makeValueGenerator makes value generator with sizes from -value_dist.
+func makeValueGenerator(r *rand.Rand) randomValueGenerator {
+	if valueSizes == nil {
+		return makeRandomValueGenerator(r, *compressionRatio, *valueSize)
 	}
-	return randomValueGenerator{b: b, k: valueSize}
+	max := 0
+	for _, n := range valueSizes {
+		max = maxInt(max, n)
+	}
+	return newRandomValueGenerator(r, *compressionRatio, meanValueSize, max, valueSizes)
 }
 
 type entryGenerator interface {
@@ -147,40 +196,33 @@ func newStartAtEntryGenerator(start int, g entryGenerator) entryGenerator {
 	return &startAtEntryGenerator{start: start, entryGenerator: g}
 }
 
-func newSequentialKeys(n int, start int, keyFormat string) [][]byte {
+func newKeys(n int, missing bool, id func(i int) int) [][]byte {
 	keys := make([][]byte, n)
-	buffer := make([]byte, n*keyLen)
+	buffer := make([]byte, 0, n*keyspace.MaxLen())
 	for i := 0; i < n; i++ {
-		begin, end := i*keyLen, (i+1)*keyLen
-		key := buffer[begin:begin:end]
-		n, _ := fmt.Fprintf(bytes.NewBuffer(key), keyFormat, start+i)
-		if n != keyLen {
-			panic("n != keyLen")
-		}
-		keys[i] = buffer[begin:end:end]
+		begin := len(buffer)
+		buffer = keyspace.AppendKey(buffer, id(i), missing)
+		keys[i] = buffer[begin:len(buffer):len(buffer)]
 	}
 	return keys
 }
 
-func newRandomKeys(n int, format string) [][]byte {
-	r := rand.New(rand.NewSource(time.Now().Unix()))
-	keys := make([][]byte, n)
-	buffer := make([]byte, n*keyLen)
-	for i := 0; i < n; i++ {
-		begin, end := i*keyLen, (i+1)*keyLen
-		key := buffer[begin:begin:end]
-		n, _ := fmt.Fprintf(bytes.NewBuffer(key), format, r.Intn(n))
-		if n != keyLen {
-			panic("n != keyLen")
-		}
-		keys[i] = buffer[begin:end:end]
-	}
-	return keys
+func newSequentialKeys(n int, start int, missing bool) [][]byte {
+	return newKeys(n, missing, func(i int) int {
+		return start + i
+	})
+}
+
+func newRandomKeys(n int, missing bool) [][]byte {
+	r := newRand()
+	return newKeys(n, missing, func(int) int {
+		return r.Intn(n)
+	})
 }
 
-func newFullRandomKeys(n int, start int, format string) [][]byte {
-	keys := newSequentialKeys(n, start, format)
-	r := rand.New(rand.NewSource(time.Now().Unix()))
+func newFullRandomKeys(n int, start int, missing bool) [][]byte {
+	keys := newSequentialKeys(n, start, missing)
+	r := newRand()
 	for i := 0; i < n; i++ {
 		j := r.Intn(n)
 		keys[i], keys[j] = keys[j], keys[i]
@@ -189,26 +231,26 @@ func newFullRandomKeys(n int, start int, format string) [][]byte {
 }
 
 func newRandomEntryGenerator(n int) entryGenerator {
-	r := rand.New(rand.NewSource(time.Now().Unix()))
+	r := newRand()
 	return &pairedEntryGenerator{
 		keyGenerator:         newRandomKeyGenerator(n),
-		randomValueGenerator: makeRandomValueGenerator(r, *compressionRatio, *valueSize),
+		randomValueGenerator: makeValueGenerator(r),
 	}
 }
 
 func newFullRandomEntryGenerator(start, n int) entryGenerator {
-	r := rand.New(rand.NewSource(time.Now().Unix()))
+	r := newRand()
 	return &pairedEntryGenerator{
 		keyGenerator:         newFullRandomKeyGenerator(start, n),
-		randomValueGenerator: makeRandomValueGenerator(r, *compressionRatio, *valueSize),
+		randomValueGenerator: makeValueGenerator(r),
 	}
 }
 
 func newSequentialEntryGenerator(n int) entryGenerator {
-	r := rand.New(rand.NewSource(time.Now().Unix()))
+	r := newRand()
 	return &pairedEntryGenerator{
 		keyGenerator:         newSequentialKeyGenerator(n),
-		randomValueGenerator: makeRandomValueGenerator(r, *compressionRatio, *valueSize),
+		randomValueGenerator: makeValueGenerator(r),
 	}
 }
 
@@ -245,6 +287,25 @@ func newRoundKeyGenerator(g keyGenerator) keyGenerator {
 	return &roundKeyGenerator{keyGenerator: g}
 }
 
+type startAtKeyGenerator struct {
+	keyGenerator
+	start int
+}
+
+var _ keyGenerator = (*startAtKeyGenerator)(nil)
+
+func (g *startAtKeyGenerator) NKey() int {
+	return g.keyGenerator.NKey() - g.start
+}
+
+func (g *startAtKeyGenerator) Key(i int) []byte {
+	return g.keyGenerator.Key(g.start + i)
+}
+
+func newStartAtKeyGenerator(start int, g keyGenerator) keyGenerator {
+	return &startAtKeyGenerator{start: start, keyGenerator: g}
+}
+
 type predefinedKeyGenerator struct {
 	keys [][]byte
 }
@@ -258,27 +319,27 @@ func (g *predefinedKeyGenerator) Key(i int) []byte {
 }
 
 func newRandomKeyGenerator(n int) keyGenerator {
-	return &predefinedKeyGenerator{keys: newRandomKeys(n, hitKeyFormat)}
+	return &predefinedKeyGenerator{keys: newRandomKeys(n, false)}
 }
 
 func newRandomMissingKeyGenerator(n int) keyGenerator {
-	return &predefinedKeyGenerator{keys: newRandomKeys(n, missingKeyFormat)}
+	return &predefinedKeyGenerator{keys: newRandomKeys(n, true)}
 }
 
 func newFullRandomKeyGenerator(start, n int) keyGenerator {
-	return &predefinedKeyGenerator{keys: newFullRandomKeys(n, start, hitKeyFormat)}
+	return &predefinedKeyGenerator{keys: newFullRandomKeys(n, start, false)}
 }
 
 func newSortedRandomKeyGenerator(n int) keyGenerator {
-	keys := newRandomKeys(n, hitKeyFormat)
+	keys := newRandomKeys(n, false)
 	sort.Slice(keys, func(i, j int) bool {
-		return bytes.Compare(keys[i], keys[j]) < 0
+		return compareKeys(keys[i], keys[j]) < 0
 	})
 	return &predefinedKeyGenerator{keys: keys}
 }
 
 func newSequentialKeyGenerator(n int) keyGenerator {
-	return &predefinedKeyGenerator{keys: newSequentialKeys(n, 0, hitKeyFormat)}
+	return &predefinedKeyGenerator{keys: newSequentialKeys(n, 0, false)}
 }
 
 func maxInt(a int, b int) int {
@@ -289,18 +350,99 @@ func maxInt(a int, b int) int {
 }
 
 func doRead(b *testing.B, db driver.DB, g keyGenerator, allowNotFound bool) {
-	for i := 0; i < b.N; i++ {
-		key := g.Key(i)
-		_, err := db.Get(key, &readOptions)
-		switch {
-		case err == nil:
-		case allowNotFound && db.IsNotFound(err):
-		default:
-			b.Fatalf("db get key[%s] error: %s\n", key, err)
+	if err := readKeys(db, b.N, *rateWorkers, g, allowNotFound); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func readKeys(db driver.DB, n int, workers int, g keyGenerator, allowNotFound bool) error {
+	return runOps(n, workers, func() (func(int) error, func()) {
+		get := newGetter(db)
+		return func(i int) error {
+			key := g.Key(i)
+			_, err := get.Get(key)
+			switch {
+			case err == nil:
+			case allowNotFound && db.IsNotFound(err):
+			default:
+				return fmt.Errorf("db get key[%s] error: %s", key, err)
+			}
+			return nil
+		}, nil
+	})
+}
+
+func seekKeys(db driver.DB, n int, workers int, g keyGenerator) error {
+	return runOps(n, workers, func() (func(int) error, func()) {
+		it := db.All(nil)
+		return func(i int) error {
+			key := g.Key(i)
+			if !it.Seek(key) {
+				return fmt.Errorf("db seek key [%s] not found, error %s", key, it.Err())
+			}
+			if !bytes.Equal(key, iterKey(it)) {
+				return fmt.Errorf("db seek key [%s] not found, got %s", key, it.Key())
+			}
+			iterValue(it)
+			return nil
+		}, func() { it.Close() }
+	})
+}
+
+// doConcurrently runs fn(i) for i in [0, parallelism) concurrently. b.N is
+// set to step, number of operations each goroutine does, during timing.
+func doConcurrently(b *testing.B, parallelism int, step int, fn func(i int) error) {
+	defer func(n int) {
+		b.N = n
+	}(b.N)
+	b.N = step
+	errs := make([]error, parallelism)
+	resetBenchmark(b)
+	var wg sync.WaitGroup
+	wg.Add(parallelism)
+	for i := 0; i < parallelism; i++ {
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	b.StopTimer()
+	for _, err := range errs {
+		if err != nil {
+			b.Fatal(err)
 		}
 	}
 }
 
+// runConcurrently runs ops in [0, b.N) by parallelism goroutines, and returns
+// number of ops run. run(start, n, workers) runs ops in [start, start+n) by
+// workers. With -rate, scheduled ops are issued by parallelism workers instead.
+func runConcurrently(b *testing.B, parallelism int, run func(start, n, workers int) error) int {
+	if *rate > 0 {
+		resetBenchmark(b)
+		err := run(0, b.N, parallelism)
+		b.StopTimer()
+		if err != nil {
+			b.Fatal(err)
+		}
+		return b.N
+	}
+	step := (b.N + parallelism - 1) / parallelism
+	doConcurrently(b, parallelism, step, func(i int) error {
+		return run(i*step, step, 1)
+	})
+	return step * parallelism
+}
+
+func runParallelismSweep(b *testing.B, build func(parallelism int) func(*testing.B)) {
+	for i, n := 1, *maxConcurrency; i <= n; i *= 2 {
+		name := fmt.Sprintf("parallelism-%d", i)
+		runtime.GC()
+		b.Run(name, build(i))
+	}
+}
+
 type DBWriter interface {
 	Put(key, value []byte)
 
@@ -350,6 +492,7 @@ func (w *batchDBWriter) writeBatch() error {
 
 func (w *batchDBWriter) checkBatch(max int) {
 	if w.count >= max {
+		countBatchBytes(w.batch.ByteSize())
 		err := w.db.Write(w.batch, &writeOptions)
 		if err != nil {
 			panic(err)
@@ -387,34 +530,38 @@ func newDBWriter(db driver.DB, batchCount int) DBWriter {
 	}
 }
 
-func doWrite(db driver.DB, n int, batchCount int, g entryGenerator) {
-	w := newDBWriter(db, batchCount)
-	for i := 0; i < n; i++ {
-		w.Put(g.Key(i), g.Value(i))
-	}
-	w.Done()
+func doWrite(db driver.DB, n int, batchCount int, workers int, g entryGenerator) {
+	runOps(n, workers, func() (func(int) error, func()) {
+		w := newDBWriter(db, batchCount)
+		return func(i int) error {
+			w.Put(g.Key(i), g.Value(i))
+			return nil
+		}, w.Done
+	})
 }
 
 func doDelete(b *testing.B, db driver.DB, k int, g keyGenerator) {
-	w := newDBWriter(db, k)
-	for i := 0; i < b.N; i++ {
-		w.Delete(g.Key(i))
-	}
-	w.Done()
+	runOps(b.N, *rateWorkers, func() (func(int) error, func()) {
+		w := newDBWriter(db, k)
+		return func(i int) error {
+			w.Delete(g.Key(i))
+			return nil
+		}, w.Done
+	})
 }
 
-func createDB(n int) (driver.DB, string) {
-	dir, err := ioutil.TempDir("", "leveldb-benchmark-")
+func createDB(parent string, opts *driver.Options) (driver.DB, string) {
+	dir, err := ioutil.TempDir(parent, "leveldb-benchmark-")
 	if err != nil {
 		panic(fmt.Errorf("temp dir create error: %s", err))
 	}
 	ok := false
 	defer func() {
 		if !ok {
-			os.RemoveAll(dir)
+			destroyDB(dir)
 		}
 	}()
-	db, err := driver.Open(*driverName, dir, &createOptions)
+	db, err := driver.Open(*driverName, dir, opts)
 	if err != nil {
 		panic(fmt.Errorf("create db %q error: %s\n", dir, err))
 	}
@@ -422,16 +569,26 @@ func createDB(n int) (driver.DB, string) {
 	return db, dir
 }
 
-func newDB(n int) string {
-	db, dir := createDB(n)
+// destroyDB destroys db in dir, falling back to removing dir if driver fails.
+func destroyDB(dir string) {
+	if err := driver.Destroy(*driverName, dir, &openOptions); err != nil {
+		fmt.Fprintf(os.Stderr, "destroy db %q error: %s\n", dir, err)
+		os.RemoveAll(dir)
+	}
+	// Temporary dir of db in memory storage is left by driver.
+	os.Remove(dir)
+}
+
+func newDB(parent string, n int) string {
+	db, dir := createDB(parent, &createOptions)
 	defer runtime.GC()
 	defer func() {
 		if db != nil {
 			db.Close()
-			os.RemoveAll(dir)
+			destroyDB(dir)
 		}
 	}()
-	doWrite(db, n, 1000, newFullRandomEntryGenerator(0, n))
+	doWrite(db, n, 1000, 1, newFullRandomEntryGenerator(0, n))
 	db.Close()
 	db = nil
 	return dir
@@ -445,27 +602,48 @@ func openDB(dir string, b *testing.B) driver.DB {
 	return db
 }
 
+// minFullDBEntries is number of entries of smallest full db.
+const minFullDBEntries = 1024
+
+// fullDBEntries returns number of entries of full db for benchmark of n
+// operations. It rounds n up to power of two, so few dbs are built, and
+// fixtures are reused though b.N varies between runs.
+func fullDBEntries(n int) int {
+	size := minFullDBEntries
+	for size < n {
+		size *= 2
+	}
+	return size
+}
+
+// openFullDB opens a private db prefilled with at least b.N entries, keys of
+// ids below b.N are all present.
 func openFullDB(b *testing.B) (driver.DB, func()) {
-	dir := newDB(b.N)
+	dir := prepareDB(fullDBEntries(b.N))
 	ok := false
 	defer func() {
 		if !ok {
-			os.RemoveAll(dir)
+			destroyDB(dir)
 		}
 	}()
 	db := openDB(dir, b)
 	ok = true
-	return db, func() { db.Close(); os.RemoveAll(dir) }
+	return db, func() { db.Close(); destroyDB(dir) }
 }
 
 func openEmptyDB(b *testing.B) (driver.DB, func()) {
+	return openEmptyDBWithOptions(b, &createOptions)
+}
+
+func openEmptyDBWithOptions(b *testing.B, opts *driver.Options) (driver.DB, func()) {
 	defer b.ResetTimer()
-	db, dir := createDB(b.N)
-	return db, func() { db.Close(); os.RemoveAll(dir) }
+	db, dir := createDB("", opts)
+	return db, func() { db.Close(); destroyDB(dir) }
 }
 
 func resetBenchmark(b *testing.B) {
 	runtime.GC()
+	startRun(b)
 	b.ResetTimer()
 }
 
@@ -479,53 +657,60 @@ func openTemplateDB() driver.DB {
 
 func BenchmarkOpen(b *testing.B) {
 	resetBenchmark(b)
+	rec := newRecorder()
 	for i := 0; i < b.N; i++ {
+		t := rec.Start()
 		openTemplateDB().Close()
+		rec.Done(t)
+	}
+}
+
+func buildConcurrentSeek(parallelism int) func(*testing.B) {
+	return func(b *testing.B) {
+		db, cleanup := openFullDB(b)
+		defer cleanup()
+		g := newRoundKeyGenerator(newRandomKeyGenerator(b.N))
+		runConcurrently(b, parallelism, func(start, n, workers int) error {
+			return seekKeys(db, n, workers, newStartAtKeyGenerator(start, g))
+		})
 	}
 }
 
 func BenchmarkSeekRandom(b *testing.B) {
-	db, cleanup := openFullDB(b)
-	defer cleanup()
-	g := newRandomKeyGenerator(b.N)
-	it := db.All(nil)
-	defer it.Close()
-	resetBenchmark(b)
-	for i := 0; i < b.N; i++ {
-		key := g.Key(i)
-		if !it.Seek(key) {
-			b.Fatalf("db seek key [%s] not found, error %s\n", key, it.Err())
-		}
-		if !bytes.Equal(key, it.Key()) {
-			b.Fatalf("db seek key [%s] not found, got %s\n", key, it.Key())
-		}
-		it.Value()
+	runParallelismSweep(b, buildConcurrentSeek)
+}
+
+// buildConcurrentRead builds benchmark reading keys from g(n) where n is
+// number of entries in db.
+func buildConcurrentRead(parallelism int, allowNotFound bool, g func(n int) keyGenerator) func(*testing.B) {
+	return func(b *testing.B) {
+		db, cleanup := openFullDB(b)
+		defer cleanup()
+		g := newRoundKeyGenerator(g(b.N))
+		runConcurrently(b, parallelism, func(start, n, workers int) error {
+			return readKeys(db, n, workers, newStartAtKeyGenerator(start, g), allowNotFound)
+		})
 	}
 }
 
 func BenchmarkReadHot(b *testing.B) {
-	db, cleanup := openFullDB(b)
-	defer cleanup()
-	k := maxInt((b.N+99)/100, 1)
-	g := newRoundKeyGenerator(newRandomKeyGenerator(k))
-	resetBenchmark(b)
-	doRead(b, db, g, false)
+	runParallelismSweep(b, func(parallelism int) func(*testing.B) {
+		return buildConcurrentRead(parallelism, false, func(n int) keyGenerator {
+			return newRandomKeyGenerator(maxInt((n+99)/100, 1))
+		})
+	})
 }
 
 func BenchmarkReadRandom(b *testing.B) {
-	db, cleanup := openFullDB(b)
-	defer cleanup()
-	g := newRandomKeyGenerator(b.N)
-	resetBenchmark(b)
-	doRead(b, db, g, false)
+	runParallelismSweep(b, func(parallelism int) func(*testing.B) {
+		return buildConcurrentRead(parallelism, false, newRandomKeyGenerator)
+	})
 }
 
 func BenchmarkReadRandomMissing(b *testing.B) {
-	db, cleanup := openFullDB(b)
-	defer cleanup()
-	g := newRandomMissingKeyGenerator(b.N)
-	resetBenchmark(b)
-	doRead(b, db, g, true)
+	runParallelismSweep(b, func(parallelism int) func(*testing.B) {
+		return buildConcurrentRead(parallelism, true, newRandomMissingKeyGenerator)
+	})
 }
 
 func BenchmarkReadSequential(b *testing.B) {
@@ -550,16 +735,19 @@ func BenchmarkIterateSequential(b *testing.B) {
 	it := db.All(nil)
 	defer it.Close()
 	resetBenchmark(b)
+	rec := newRecorder()
 	it.First()
 	for i := 0; i < b.N; i++ {
+		t := rec.Start()
 		switch it.Valid() {
 		case false:
 			it.First()
 		default:
-			it.Key()
-			it.Value()
+			iterKey(it)
+			iterValue(it)
 			it.Next()
 		}
+		rec.Done(t)
 	}
 }
 
@@ -569,16 +757,19 @@ func BenchmarkIterateReverse(b *testing.B) {
 	it := db.All(nil)
 	defer it.Close()
 	resetBenchmark(b)
+	rec := newRecorder()
 	it.Last()
 	for i := 0; i < b.N; i++ {
+		t := rec.Start()
 		switch it.Valid() {
 		case false:
 			it.Last()
 		default:
-			it.Key()
-			it.Value()
+			iterKey(it)
+			iterValue(it)
 			it.Prev()
 		}
+		rec.Done(t)
 	}
 }
 
@@ -587,44 +778,23 @@ func BenchmarkWriteSequential(b *testing.B) {
 	defer cleanup()
 	g := newSequentialEntryGenerator(b.N)
 	resetBenchmark(b)
-	doWrite(db, b.N, *batchCount, g)
+	doWrite(db, b.N, *batchCount, *rateWorkers, g)
 }
 
 func buildConcurrentWrite(parallelism int) func(*testing.B) {
 	return func(b *testing.B) {
 		db, cleanup := openEmptyDB(b)
 		defer cleanup()
-		var gens []entryGenerator
-		start, step := 0, (b.N+parallelism)/parallelism
-		n := step * parallelism
-		g := newFullRandomEntryGenerator(0, n)
-		for i := 0; i < parallelism; i++ {
-			gens = append(gens, newStartAtEntryGenerator(start, g))
-			start += step
-		}
-		defer func(n int) {
-			b.N = n
-		}(b.N)
-		b.N = step
-		resetBenchmark(b)
-		var wg sync.WaitGroup
-		wg.Add(len(gens))
-		for _, g := range gens {
-			go func(g entryGenerator) {
-				defer wg.Done()
-				doWrite(db, b.N, *batchCount, g)
-			}(g)
-		}
-		wg.Wait()
+		g := newFullRandomEntryGenerator(0, (b.N+parallelism-1)/parallelism*parallelism)
+		runConcurrently(b, parallelism, func(start, n, workers int) error {
+			doWrite(db, n, *batchCount, workers, newStartAtEntryGenerator(start, g))
+			return nil
+		})
 	}
 }
 
 func BenchmarkWriteRandom(b *testing.B) {
-	for i, n := 1, *maxConcurrency; i <= n; i *= 2 {
-		name := fmt.Sprintf("parallelism-%d", i)
-		runtime.GC()
-		b.Run(name, buildConcurrentWrite(i))
-	}
+	runParallelismSweep(b, buildConcurrentWrite)
 }
 
 func BenchmarkDeleteRandom(b *testing.B) {
@@ -646,7 +816,28 @@ func BenchmarkDeleteSequential(b *testing.B) {
 func TestMain(m *testing.M) {
 	flag.Parse()
 	initOptions()
-	templateDBDir = newDB(*openDBSize / *valueSize)
-	defer os.RemoveAll(templateDBDir)
-	os.Exit(m.Run())
+	inits := []func() error{
+		initReport,
+		initComparer,
+		initGC,
+		initProfiles,
+		initStorage,
+		initKeySchema,
+		initValueSizes,
+	}
+	for _, f := range inits {
+		if err := f(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	templateDBEntries = *openDBSize / meanValueSize
+	templateDBDir = prepareDB(templateDBEntries)
+	code := runGOGCSweep(m)
+	destroyDB(templateDBDir)
+	if err := writeReport(); err != nil {
+		fmt.Fprintf(os.Stderr, "write report %q error: %s\n", *reportFile, err)
+		code = 1
+	}
+	os.Exit(code)
 }
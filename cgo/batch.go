@@ -0,0 +1,89 @@
+package cgo
+
+import (
+	"encoding/binary"
+	"runtime"
+	runtimecgo "runtime/cgo"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+// #include <stdint.h>
+// #include <leveldb/c.h>
+//
+// extern void goBatchPut(uintptr_t, char*, size_t, char*, size_t);
+// extern void goBatchDelete(uintptr_t, char*, size_t);
+//
+// static void batch_put(void* state, const char* k, size_t klen, const char* v, size_t vlen) {
+// 	goBatchPut((uintptr_t)state, (char*)k, klen, (char*)v, vlen);
+// }
+//
+// static void batch_delete(void* state, const char* k, size_t klen) {
+// 	goBatchDelete((uintptr_t)state, (char*)k, klen);
+// }
+//
+// static void batch_iterate(const leveldb_writebatch_t* b, uintptr_t handle) {
+// 	leveldb_writebatch_iterate(b, (void*)handle, batch_put, batch_delete);
+// }
+import "C"
+
+// batch counts updates and size in Go, as C api does not expose them.
+type batch struct {
+	batch *C.leveldb_writebatch_t
+	count int
+	size  int
+}
+
+func uvarintSize(n int) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], uint64(n))
+}
+
+func (b *batch) Put(key, value []byte) {
+	kp, kn := bytes2chars(key)
+	vp, vn := bytes2chars(value)
+	C.leveldb_writebatch_put(b.batch, kp, kn, vp, vn)
+	b.count++
+	b.size += 1 + uvarintSize(len(key)) + len(key) + uvarintSize(len(value)) + len(value)
+}
+
+func (b *batch) Delete(key []byte) {
+	kp, kn := bytes2chars(key)
+	C.leveldb_writebatch_delete(b.batch, kp, kn)
+	b.count++
+	b.size += 1 + uvarintSize(len(key)) + len(key)
+}
+
+func (b *batch) Clear() {
+	C.leveldb_writebatch_clear(b.batch)
+	b.count = 0
+	b.size = 0
+}
+
+func (b *batch) Len() int {
+	return b.count
+}
+
+func (b *batch) ByteSize() int {
+	return driver.BatchHeaderSize + b.size
+}
+
+// Replay replays updates by leveldb_writebatch_iterate, every update
+// crosses cgo boundary from C to Go. Replay is referenced from C by handle,
+// as C must not keep Go pointers.
+func (b *batch) Replay(r driver.BatchReplay) error {
+	handle := runtimecgo.NewHandle(r)
+	defer handle.Delete()
+	C.batch_iterate(b.batch, C.uintptr_t(handle))
+	return nil
+}
+
+func (b *batch) finalize() {
+	C.leveldb_writebatch_destroy(b.batch)
+}
+
+func (db *DB) Batch() driver.Batch {
+	b := &batch{batch: C.leveldb_writebatch_create()}
+	runtime.SetFinalizer(b, (*batch).finalize)
+	return b
+}
@@ -0,0 +1,43 @@
+package cgo
+
+import (
+	runtimecgo "runtime/cgo"
+	"unsafe"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+// #include <stdint.h>
+// #include <stdlib.h>
+import "C"
+
+// Exported functions are defined in this file, as C definitions are not
+// allowed in preamble of files using //export.
+
+//export goComparatorCompare
+func goComparatorCompare(handle C.uintptr_t, a *C.char, alen C.size_t, b *C.char, blen C.size_t) C.int {
+	c := lookupComparator(uintptr(handle))
+	return C.int(c.c.Compare(charsToBytes(a, alen), charsToBytes(b, blen)))
+}
+
+//export goComparatorName
+func goComparatorName(handle C.uintptr_t) *C.char {
+	return lookupComparator(uintptr(handle)).name
+}
+
+//export goComparatorDestroy
+func goComparatorDestroy(handle C.uintptr_t) {
+	c := lookupComparator(uintptr(handle))
+	runtimecgo.Handle(handle).Delete()
+	C.free(unsafe.Pointer(c.name))
+}
+
+//export goBatchPut
+func goBatchPut(handle C.uintptr_t, k *C.char, klen C.size_t, v *C.char, vlen C.size_t) {
+	runtimecgo.Handle(handle).Value().(driver.BatchReplay).Put(charsToBytes(k, klen), charsToBytes(v, vlen))
+}
+
+//export goBatchDelete
+func goBatchDelete(handle C.uintptr_t, k *C.char, klen C.size_t) {
+	runtimecgo.Handle(handle).Value().(driver.BatchReplay).Delete(charsToBytes(k, klen))
+}
@@ -0,0 +1,50 @@
+package cgo
+
+import (
+	runtimecgo "runtime/cgo"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+// #include <stdint.h>
+// #include <leveldb/c.h>
+//
+// extern int goComparatorCompare(uintptr_t, char*, size_t, char*, size_t);
+// extern char* goComparatorName(uintptr_t);
+// extern void goComparatorDestroy(uintptr_t);
+//
+// static int comparator_compare(void* state, const char* a, size_t alen, const char* b, size_t blen) {
+// 	return goComparatorCompare((uintptr_t)state, (char*)a, alen, (char*)b, blen);
+// }
+//
+// static const char* comparator_name(void* state) {
+// 	return goComparatorName((uintptr_t)state);
+// }
+//
+// static void comparator_destroy(void* state) {
+// 	goComparatorDestroy((uintptr_t)state);
+// }
+//
+// static leveldb_comparator_t* comparator_create(uintptr_t handle) {
+// 	return leveldb_comparator_create((void*)handle, comparator_destroy, comparator_compare, comparator_name);
+// }
+import "C"
+
+type comparator struct {
+	c    *driver.Comparer
+	name *C.char
+}
+
+// Comparators are referenced from C by handles, as C must not keep Go
+// pointers. Handles are resolved without locking, as every comparison looks
+// up its comparator.
+func lookupComparator(handle uintptr) *comparator {
+	return runtimecgo.Handle(handle).Value().(*comparator)
+}
+
+// newComparator creates a C comparator which calls back to c. Every
+// comparison crosses cgo boundary from C to Go.
+func newComparator(c *driver.Comparer) *C.leveldb_comparator_t {
+	handle := runtimecgo.NewHandle(&comparator{c: c, name: C.CString(c.Name)})
+	return C.comparator_create(C.uintptr_t(handle))
+}
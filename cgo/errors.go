@@ -0,0 +1,52 @@
+package cgo
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotFound        = errors.New("leveldb: key not found")
+	ErrCorruption      = errors.New("leveldb: corruption")
+	ErrNotSupported    = errors.New("leveldb: not implemented")
+	ErrInvalidArgument = errors.New("leveldb: invalid argument")
+	ErrIO              = errors.New("leveldb: io error")
+)
+
+// Error is an error status of leveldb. It wraps one of ErrNotFound,
+// ErrCorruption, ErrNotSupported, ErrInvalidArgument and ErrIO, or nil if
+// status is unknown, so it can be classified with errors.Is.
+type Error struct {
+	Kind error
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// See Status::ToString of leveldb.
+var statusPrefixes = []struct {
+	prefix string
+	kind   error
+}{
+	{"NotFound: ", ErrNotFound},
+	{"Corruption: ", ErrCorruption},
+	{"Not implemented: ", ErrNotSupported},
+	{"Invalid argument: ", ErrInvalidArgument},
+	{"IO error: ", ErrIO},
+}
+
+// parseStatus parses error string of leveldb status.
+func parseStatus(msg string) error {
+	for _, s := range statusPrefixes {
+		if strings.HasPrefix(msg, s.prefix) {
+			return &Error{Kind: s.kind, Msg: msg}
+		}
+	}
+	return &Error{Msg: msg}
+}
@@ -0,0 +1,43 @@
+package cgo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	kinds := []error{ErrNotFound, ErrCorruption, ErrNotSupported, ErrInvalidArgument, ErrIO}
+	tests := []struct {
+		msg  string
+		kind error
+	}{
+		{"NotFound: key", ErrNotFound},
+		{"Corruption: bad block checksum", ErrCorruption},
+		{"Not implemented: reuse logs", ErrNotSupported},
+		{"Invalid argument: db exists (error_if_exists is true)", ErrInvalidArgument},
+		{"IO error: /tmp/db/LOCK: No such file or directory", ErrIO},
+		{"Unknown code(9): weird", nil},
+		{"corruption: lower case", nil},
+		{"", nil},
+	}
+	db := &DB{}
+	for _, test := range tests {
+		err := parseStatus(test.msg)
+		if err.Error() != test.msg {
+			t.Errorf("parseStatus(%q).Error() = %q", test.msg, err.Error())
+		}
+		wrapped := fmt.Errorf("wrapped: %w", err)
+		for _, kind := range kinds {
+			if got, want := errors.Is(wrapped, kind), kind == test.kind; got != want {
+				t.Errorf("errors.Is(parseStatus(%q), %v) = %t, want %t", test.msg, kind, got, want)
+			}
+		}
+		if got, want := db.IsNotFound(err), test.kind == ErrNotFound; got != want {
+			t.Errorf("IsNotFound(parseStatus(%q)) = %t, want %t", test.msg, got, want)
+		}
+		if got, want := db.IsCorrupt(err), test.kind == ErrCorruption; got != want {
+			t.Errorf("IsCorrupt(parseStatus(%q)) = %t, want %t", test.msg, got, want)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package cgo
 
 import (
 	"errors"
-	"runtime"
 	"unsafe"
 
 	"github.com/kezhuw/go-leveldb-benchmarks/driver"
@@ -14,12 +13,11 @@ import (
 // #include <stdlib.h>
 import "C"
 
-var ErrNotFound = errors.New("leveldb: key not found")
-
 type DB struct {
-	db     *C.leveldb_t
-	filter *C.leveldb_filterpolicy_t
-	cache  *C.leveldb_cache_t
+	db         *C.leveldb_t
+	filter     *C.leveldb_filterpolicy_t
+	cache      *C.leveldb_cache_t
+	comparator *C.leveldb_comparator_t
 }
 
 func bool2uchar(b bool) C.uchar {
@@ -38,7 +36,7 @@ func uchar2bool(u C.uchar) bool {
 
 func str2error(str *C.char) error {
 	defer C.free(unsafe.Pointer(str))
-	return errors.New(C.GoString(str))
+	return parseStatus(C.GoString(str))
 }
 
 func bytes2chars(b []byte) (*C.char, C.size_t) {
@@ -48,6 +46,14 @@ func bytes2chars(b []byte) (*C.char, C.size_t) {
 	return (*C.char)(unsafe.Pointer(&b[0])), C.size_t(len(b))
 }
 
+// charsToBytes returns slice referencing C memory of n bytes at p.
+func charsToBytes(p *C.char, n C.size_t) []byte {
+	if n == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(p)), n)
+}
+
 type iterator struct {
 	it  *C.leveldb_iterator_t
 	run bool
@@ -110,6 +116,18 @@ func (it *iterator) Value() []byte {
 	return C.GoBytes(unsafe.Pointer(p), C.int(n))
 }
 
+func (it *iterator) KeyUnsafe() []byte {
+	var n C.size_t
+	p := C.leveldb_iter_key(it.it, &n)
+	return charsToBytes(p, n)
+}
+
+func (it *iterator) ValueUnsafe() []byte {
+	var n C.size_t
+	p := C.leveldb_iter_value(it.it, &n)
+	return charsToBytes(p, n)
+}
+
 func (it *iterator) Close() error {
 	err := it.Err()
 	C.leveldb_iter_destroy(it.it)
@@ -125,35 +143,6 @@ func (it *iterator) Err() error {
 	return str2error(errstr)
 }
 
-type batch struct {
-	batch *C.leveldb_writebatch_t
-}
-
-func (b *batch) Put(key, value []byte) {
-	kp, kn := bytes2chars(key)
-	vp, vn := bytes2chars(value)
-	C.leveldb_writebatch_put(b.batch, kp, kn, vp, vn)
-}
-
-func (b *batch) Delete(key []byte) {
-	kp, kn := bytes2chars(key)
-	C.leveldb_writebatch_delete(b.batch, kp, kn)
-}
-
-func (b *batch) Clear() {
-	C.leveldb_writebatch_clear(b.batch)
-}
-
-func (b *batch) finalize() {
-	C.leveldb_writebatch_destroy(b.batch)
-}
-
-func (db *DB) Batch() driver.Batch {
-	b := &batch{C.leveldb_writebatch_create()}
-	runtime.SetFinalizer(b, (*batch).finalize)
-	return b
-}
-
 func (db *DB) All(opts *driver.ReadOptions) driver.Iterator {
 	copts := convertReadOptions(opts)
 	defer C.leveldb_readoptions_destroy(copts)
@@ -161,7 +150,8 @@ func (db *DB) All(opts *driver.ReadOptions) driver.Iterator {
 	return &iterator{it: it}
 }
 
-func (db *DB) Get(key []byte, opts *driver.ReadOptions) ([]byte, error) {
+// get returns value of key in malloc'd memory, which must be freed by caller.
+func (db *DB) get(key []byte, opts *driver.ReadOptions) (*C.char, C.size_t, error) {
 	copts := convertReadOptions(opts)
 	defer C.leveldb_readoptions_destroy(copts)
 	var errstr *C.char
@@ -169,15 +159,34 @@ func (db *DB) Get(key []byte, opts *driver.ReadOptions) ([]byte, error) {
 	kp, kn := bytes2chars(key)
 	value := C.leveldb_get(db.db, copts, kp, kn, &valueLen, &errstr)
 	if errstr != nil {
-		return nil, str2error(errstr)
+		return nil, 0, str2error(errstr)
 	}
 	if value == nil {
-		return nil, ErrNotFound
+		return nil, 0, ErrNotFound
+	}
+	return value, valueLen, nil
+}
+
+func (db *DB) Get(key []byte, opts *driver.ReadOptions) ([]byte, error) {
+	value, valueLen, err := db.get(key, opts)
+	if err != nil {
+		return nil, err
 	}
 	defer C.free(unsafe.Pointer(value))
 	return C.GoBytes(unsafe.Pointer(value), C.int(valueLen)), nil
 }
 
+// GetInto appends value of key to dst. Value is still copied out of C memory,
+// only allocation of Go memory is saved.
+func (db *DB) GetInto(dst, key []byte, opts *driver.ReadOptions) ([]byte, error) {
+	value, valueLen, err := db.get(key, opts)
+	if err != nil {
+		return dst, err
+	}
+	defer C.free(unsafe.Pointer(value))
+	return append(dst, charsToBytes(value, valueLen)...), nil
+}
+
 func (db *DB) Put(key, value []byte, opts *driver.WriteOptions) error {
 	copts := convertWriteOptions(opts)
 	defer C.leveldb_writeoptions_destroy(copts)
@@ -214,8 +223,22 @@ func (db *DB) Write(writes driver.Batch, opts *driver.WriteOptions) error {
 	return nil
 }
 
+func (db *DB) CompactRange(start, limit []byte) error {
+	sp, sn := bytes2chars(start)
+	lp, ln := bytes2chars(limit)
+	C.leveldb_compact_range(db.db, sp, sn, lp, ln)
+	return nil
+}
+
 func (db *DB) Close() error {
 	C.leveldb_close(db.db)
+	db.release()
+	return nil
+}
+
+// release releases resources referenced by options. It must be called after
+// db closed.
+func (db *DB) release() {
 	if db.cache != nil {
 		C.leveldb_cache_destroy(db.cache)
 		db.cache = nil
@@ -224,35 +247,60 @@ func (db *DB) Close() error {
 		C.leveldb_filterpolicy_destroy(db.filter)
 		db.filter = nil
 	}
-	return nil
+	if db.comparator != nil {
+		C.leveldb_comparator_destroy(db.comparator)
+		db.comparator = nil
+	}
 }
 
 func (db *DB) IsNotFound(err error) bool {
-	return err == ErrNotFound
+	return errors.Is(err, ErrNotFound)
 }
 
 func (db *DB) IsCorrupt(err error) bool {
-	return false
-}
-
-func convertOptions(dopts *driver.Options) (*C.leveldb_options_t, *C.leveldb_filterpolicy_t, *C.leveldb_cache_t) {
+	return errors.Is(err, ErrCorruption)
+}
+
+// convertOptions converts dopts to C options. Resources referenced by C
+// options are stored in db.
+func convertOptions(dopts *driver.Options, db *DB) (*C.leveldb_options_t, error) {
+	switch {
+	case dopts.ReuseLogs:
+		return nil, &driver.UnsupportedOptionError{Driver: "cgo", Option: "ReuseLogs"}
+	case dopts.Storage != driver.OSStorage:
+		return nil, &driver.UnsupportedOptionError{Driver: "cgo", Option: "Storage"}
+	case dopts.StorageStats != nil:
+		return nil, &driver.UnsupportedOptionError{Driver: "cgo", Option: "StorageStats"}
+	}
 	copts := C.leveldb_options_create()
 	if dopts.MaxOpenFiles > 0 {
 		C.leveldb_options_set_max_open_files(copts, C.int(dopts.MaxOpenFiles))
 	}
-	var filter *C.leveldb_filterpolicy_t
+	if dopts.Comparer != nil {
+		db.comparator = newComparator(dopts.Comparer)
+		C.leveldb_options_set_comparator(copts, db.comparator)
+	}
 	if dopts.BloomBitsPerKey > 0 {
-		filter = C.leveldb_filterpolicy_create_bloom(C.int(dopts.BloomBitsPerKey))
-		C.leveldb_options_set_filter_policy(copts, filter)
+		db.filter = C.leveldb_filterpolicy_create_bloom(C.int(dopts.BloomBitsPerKey))
+		C.leveldb_options_set_filter_policy(copts, db.filter)
 	}
 	if dopts.WriteBufferSize > 0 {
 		C.leveldb_options_set_write_buffer_size(copts, C.size_t(dopts.WriteBufferSize))
 	}
-	var cache *C.leveldb_cache_t
 	if dopts.BlockCacheCapacity > 0 {
-		cache = C.leveldb_cache_create_lru(C.size_t(dopts.BlockCacheCapacity))
-		C.leveldb_options_set_cache(copts, cache)
+		db.cache = C.leveldb_cache_create_lru(C.size_t(dopts.BlockCacheCapacity))
+		C.leveldb_options_set_cache(copts, db.cache)
+	}
+	if dopts.BlockSize > 0 {
+		C.leveldb_options_set_block_size(copts, C.size_t(dopts.BlockSize))
+	}
+	if dopts.BlockRestartInterval > 0 {
+		C.leveldb_options_set_block_restart_interval(copts, C.int(dopts.BlockRestartInterval))
+	}
+	if dopts.MaxFileSize > 0 {
+		C.leveldb_options_set_max_file_size(copts, C.size_t(dopts.MaxFileSize))
 	}
+	C.leveldb_options_set_paranoid_checks(copts, bool2uchar(dopts.ParanoidChecks))
 	C.leveldb_options_set_create_if_missing(copts, bool2uchar(dopts.CreateIfMissing))
 	C.leveldb_options_set_error_if_exists(copts, bool2uchar(dopts.ErrorIfExists))
 	switch dopts.Compression {
@@ -261,7 +309,7 @@ func convertOptions(dopts *driver.Options) (*C.leveldb_options_t, *C.leveldb_fil
 	case driver.SnappyCompression:
 		C.leveldb_options_set_compression(copts, C.leveldb_snappy_compression)
 	}
-	return copts, filter, cache
+	return copts, nil
 }
 
 func convertReadOptions(dopts *driver.ReadOptions) *C.leveldb_readoptions_t {
@@ -289,18 +337,52 @@ type driverType struct {
 }
 
 func (driverType) Open(dir string, dopts *driver.Options) (driver.DB, error) {
-	copts, filter, cache := convertOptions(dopts)
+	db := &DB{}
+	copts, err := convertOptions(dopts, db)
+	if err != nil {
+		return nil, err
+	}
 	defer C.leveldb_options_destroy(copts)
 	cdir := C.CString(dir)
 	defer C.free(unsafe.Pointer(cdir))
 	var errstr *C.char
-	db := C.leveldb_open(copts, cdir, &errstr)
-	if db == nil {
-		C.leveldb_filterpolicy_destroy(filter)
-		C.leveldb_cache_destroy(cache)
+	db.db = C.leveldb_open(copts, cdir, &errstr)
+	if db.db == nil {
+		db.release()
 		return nil, str2error(errstr)
 	}
-	return &DB{db: db, filter: filter, cache: cache}, nil
+	return db, nil
+}
+
+func (driverType) Destroy(dir string, dopts *driver.Options) error {
+	copts := C.leveldb_options_create()
+	defer C.leveldb_options_destroy(copts)
+	cdir := C.CString(dir)
+	defer C.free(unsafe.Pointer(cdir))
+	var errstr *C.char
+	C.leveldb_destroy_db(copts, cdir, &errstr)
+	if errstr != nil {
+		return str2error(errstr)
+	}
+	return nil
+}
+
+func (driverType) Repair(dir string, dopts *driver.Options) error {
+	db := &DB{}
+	copts, err := convertOptions(dopts, db)
+	if err != nil {
+		return err
+	}
+	defer db.release()
+	defer C.leveldb_options_destroy(copts)
+	cdir := C.CString(dir)
+	defer C.free(unsafe.Pointer(cdir))
+	var errstr *C.char
+	C.leveldb_repair_db(copts, cdir, &errstr)
+	if errstr != nil {
+		return str2error(errstr)
+	}
+	return nil
 }
 
 func init() {
@@ -0,0 +1,36 @@
+package cgo
+
+// #include <stdlib.h>
+// #if defined(__APPLE__)
+// #include <malloc/malloc.h>
+// #elif defined(__GLIBC__)
+// #include <malloc.h>
+// #endif
+//
+// static int malloc_inuse(size_t* n) {
+// #if defined(__APPLE__)
+// 	*n = mstats().bytes_used;
+// 	return 1;
+// #elif defined(__GLIBC__) && (__GLIBC__ > 2 || (__GLIBC__ == 2 && __GLIBC_MINOR__ >= 33))
+// 	struct mallinfo2 mi = mallinfo2();
+// 	*n = mi.uordblks + mi.hblkhd;
+// 	return 1;
+// #elif defined(__GLIBC__)
+// 	struct mallinfo mi = mallinfo();
+// 	*n = (size_t)(unsigned)mi.uordblks + (size_t)(unsigned)mi.hblkhd;
+// 	return 1;
+// #else
+// 	return 0;
+// #endif
+// }
+import "C"
+
+// HeapInuse returns bytes allocated by malloc and not yet freed in process.
+// It returns false if malloc statistics are unavailable.
+func HeapInuse() (int64, bool) {
+	var n C.size_t
+	if C.malloc_inuse(&n) == 0 {
+		return 0, false
+	}
+	return int64(n), true
+}
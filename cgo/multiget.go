@@ -0,0 +1,61 @@
+package cgo
+
+import (
+	"unsafe"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+// #include <leveldb/c.h>
+// #include <stdlib.h>
+//
+// // multi_get gets values of n keys, key i is keys[offsets[i]:offsets[i+1]].
+// static void multi_get(leveldb_t* db, const leveldb_readoptions_t* options,
+// 		size_t n, const char* keys, const size_t* offsets,
+// 		char** values, size_t* value_lens, char** errs) {
+// 	for (size_t i = 0; i < n; i++) {
+// 		errs[i] = NULL;
+// 		values[i] = leveldb_get(db, options, keys + offsets[i], offsets[i+1] - offsets[i], &value_lens[i], &errs[i]);
+// 	}
+// }
+import "C"
+
+// MultiGet gets values of keys in one cgo call. Keys are packed in one
+// buffer, as memory passed to C must not contain Go pointers.
+func (db *DB) MultiGet(keys [][]byte, opts *driver.ReadOptions) ([][]byte, []error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	if len(keys) == 0 {
+		return values, errs
+	}
+	n := len(keys)
+	offsets := make([]C.size_t, n+1)
+	size := 0
+	for i, key := range keys {
+		size += len(key)
+		offsets[i+1] = C.size_t(size)
+	}
+	buf := make([]byte, 0, size)
+	for _, key := range keys {
+		buf = append(buf, key...)
+	}
+	cvalues := make([]*C.char, n)
+	lens := make([]C.size_t, n)
+	cerrs := make([]*C.char, n)
+	copts := convertReadOptions(opts)
+	defer C.leveldb_readoptions_destroy(copts)
+	kp, _ := bytes2chars(buf)
+	C.multi_get(db.db, copts, C.size_t(n), kp, &offsets[0], &cvalues[0], &lens[0], &cerrs[0])
+	for i := range keys {
+		switch {
+		case cerrs[i] != nil:
+			errs[i] = str2error(cerrs[i])
+		case cvalues[i] == nil:
+			errs[i] = ErrNotFound
+		default:
+			values[i] = C.GoBytes(unsafe.Pointer(cvalues[i]), C.int(lens[i]))
+			C.free(unsafe.Pointer(cvalues[i]))
+		}
+	}
+	return values, errs
+}
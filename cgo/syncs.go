@@ -0,0 +1,43 @@
+//go:build linux && count_syncs
+
+package cgo
+
+// #cgo CFLAGS: -D_GNU_SOURCE
+// #cgo LDFLAGS: -ldl
+// #include <stdint.h>
+// #include <dlfcn.h>
+//
+// static uint64_t sync_count;
+//
+// // fsync and fdatasync interpose libc ones for leveldb, Go syncs files by
+// // system calls directly. They are exported from binary, so they replace
+// // libc ones for all C code in process, not only leveldb.
+// int fsync(int fd) {
+// 	static int (*next)(int);
+// 	if (next == NULL) {
+// 		next = (int (*)(int))dlsym(RTLD_NEXT, "fsync");
+// 	}
+// 	__atomic_add_fetch(&sync_count, 1, __ATOMIC_RELAXED);
+// 	return next(fd);
+// }
+//
+// int fdatasync(int fd) {
+// 	static int (*next)(int);
+// 	if (next == NULL) {
+// 		next = (int (*)(int))dlsym(RTLD_NEXT, "fdatasync");
+// 	}
+// 	__atomic_add_fetch(&sync_count, 1, __ATOMIC_RELAXED);
+// 	return next(fd);
+// }
+//
+// static uint64_t load_sync_count(void) {
+// 	return __atomic_load_n(&sync_count, __ATOMIC_RELAXED);
+// }
+import "C"
+
+// Syncs returns number of fsync and fdatasync calls from C code in process,
+// including leveldb. Calls are counted by interposing libc functions for
+// whole process, which is enabled by build tag count_syncs on Linux.
+func Syncs() (int64, bool) {
+	return int64(C.load_sync_count()), true
+}
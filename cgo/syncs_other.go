@@ -0,0 +1,9 @@
+//go:build !linux || !count_syncs
+
+package cgo
+
+// Syncs returns false as syncs are counted only with build tag count_syncs on
+// Linux.
+func Syncs() (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,69 @@
+// Command leveldb-report generates a self-contained html report from records
+// written by "go test -bench . -report FILE".
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/report"
+)
+
+var output = flag.String("o", "", "Output file, default to stdout")
+var title = flag.String("title", "LevelDB Benchmarks", "Title of report")
+
+func readRecords(files []string) ([]report.Record, error) {
+	var records []report.Record
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		rs, err := report.ReadRecords(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		records = append(records, rs...)
+	}
+	return records, nil
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] records.jsonl...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	records, err := readRecords(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read records: %s\n", err)
+		os.Exit(1)
+	}
+	f := os.Stdout
+	if *output != "" {
+		f, err = os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "create output: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	w := bufio.NewWriter(f)
+	err = report.WriteHTML(w, *title, records)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write report: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,96 @@
+package leveldb_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+var comparerName = flag.String("comparer", "", "Comparer implemented in Go: bytewise, reverse or numeric, empty for builtin bytewise comparer")
+
+// bytewiseComparer orders keys as builtin comparer. Comparing it with builtin
+// comparer measures cost of calling comparer implemented in Go, which is
+// significant for cgo driver.
+var bytewiseComparer = &driver.Comparer{
+	Name:    "benchmarks.bytewise",
+	Compare: bytes.Compare,
+}
+
+var reverseComparer = &driver.Comparer{
+	Name: "benchmarks.reverse",
+	Compare: func(a, b []byte) int {
+		return bytes.Compare(b, a)
+	},
+}
+
+// numericComparer orders keys by their leading decimal numbers, ignoring
+// leading zeros, and then by remaining bytes.
+var numericComparer = &driver.Comparer{
+	Name:    "benchmarks.numeric",
+	Compare: compareNumeric,
+}
+
+func splitNumber(b []byte) (number, rest []byte) {
+	i := 0
+	for i < len(b) && b[i] == '0' {
+		i++
+	}
+	j := i
+	for j < len(b) && b[j] >= '0' && b[j] <= '9' {
+		j++
+	}
+	return b[i:j], b[j:]
+}
+
+func compareNumeric(a, b []byte) int {
+	na, ra := splitNumber(a)
+	nb, rb := splitNumber(b)
+	switch {
+	case len(na) < len(nb):
+		return -1
+	case len(na) > len(nb):
+		return 1
+	}
+	if c := bytes.Compare(na, nb); c != 0 {
+		return c
+	}
+	if c := bytes.Compare(ra, rb); c != 0 {
+		return c
+	}
+	// Keys differ in leading zeros only.
+	return bytes.Compare(a, b)
+}
+
+func newComparer() (*driver.Comparer, error) {
+	switch *comparerName {
+	case "":
+		return nil, nil
+	case "bytewise":
+		return bytewiseComparer, nil
+	case "reverse":
+		return reverseComparer, nil
+	case "numeric":
+		return numericComparer, nil
+	}
+	return nil, fmt.Errorf("unknown comparer: %s", *comparerName)
+}
+
+func initComparer() error {
+	comparer, err := newComparer()
+	if err != nil {
+		return err
+	}
+	openOptions.Comparer = comparer
+	createOptions.Comparer = comparer
+	return nil
+}
+
+// compareKeys compares keys in order of db.
+func compareKeys(a, b []byte) int {
+	if c := openOptions.Comparer; c != nil {
+		return c.Compare(a, b)
+	}
+	return bytes.Compare(a, b)
+}
@@ -1,7 +1,133 @@
 package driver
 
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// BatchHeaderSize is size of header of batch in LevelDB representation.
+const BatchHeaderSize = 12
+
 type Batch interface {
 	Put(key, value []byte)
 	Delete(key []byte)
 	Clear()
+
+	// Len returns number of updates in batch.
+	Len() int
+
+	// ByteSize returns size of batch in LevelDB representation, including
+	// header.
+	ByteSize() int
+
+	// Replay replays updates of batch in order. It fails in drivers which
+	// require option ReplayableBatches if db is opened without it.
+	Replay(r BatchReplay) error
+}
+
+// BatchReplay receives updates of batch. Keys and values are valid only
+// during the call.
+type BatchReplay interface {
+	Put(key, value []byte)
+	Delete(key []byte)
+}
+
+// ErrBatchCorrupt is returned when decoding malformed batch data.
+var ErrBatchCorrupt = errors.New("leveldb: corrupted batch")
+
+type batchEncoder struct {
+	data []byte
+}
+
+func (e *batchEncoder) appendLengthPrefixed(b []byte) {
+	e.data = appendUvarint(e.data, uint64(len(b)))
+	e.data = append(e.data, b...)
+}
+
+func (e *batchEncoder) Put(key, value []byte) {
+	e.data = append(e.data, 1)
+	e.appendLengthPrefixed(key)
+	e.appendLengthPrefixed(value)
+}
+
+func (e *batchEncoder) Delete(key []byte) {
+	e.data = append(e.data, 0)
+	e.appendLengthPrefixed(key)
+}
+
+func appendUvarint(dst []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(dst, buf[:n]...)
+}
+
+// EncodeBatch appends b in LevelDB representation to dst and returns the
+// extended buffer. Sequence number in header is zero.
+func EncodeBatch(dst []byte, b Batch) ([]byte, error) {
+	var header [BatchHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[8:], uint32(b.Len()))
+	e := batchEncoder{data: append(dst, header[:]...)}
+	if err := b.Replay(&e); err != nil {
+		return dst, err
+	}
+	return e.data, nil
+}
+
+// DecodeBatch appends updates of data in LevelDB representation to b. b is
+// left unchanged if data is corrupted.
+func DecodeBatch(data []byte, b Batch) error {
+	return ReplayBatch(data, b)
+}
+
+func readLengthPrefixed(data []byte) (bytes, remains []byte, err error) {
+	n, i := binary.Uvarint(data)
+	if i <= 0 || uint64(len(data)-i) < n {
+		return nil, nil, ErrBatchCorrupt
+	}
+	return data[i : i+int(n)], data[i+int(n):], nil
+}
+
+// ReplayBatch replays updates of data in LevelDB representation. Keys and
+// values passed to r alias data. Data is validated as a whole before replay,
+// so r receives no update from corrupted data.
+func ReplayBatch(data []byte, r BatchReplay) error {
+	if err := walkBatch(data, nil); err != nil {
+		return err
+	}
+	return walkBatch(data, r)
+}
+
+// walkBatch walks records of data, and replays them to r if r is not nil.
+func walkBatch(data []byte, r BatchReplay) error {
+	if len(data) < BatchHeaderSize {
+		return ErrBatchCorrupt
+	}
+	count := int(binary.LittleEndian.Uint32(data[8:]))
+	var key, value []byte
+	var err error
+	for data = data[BatchHeaderSize:]; len(data) != 0; count-- {
+		kind := data[0]
+		if key, data, err = readLengthPrefixed(data[1:]); err != nil {
+			return err
+		}
+		switch kind {
+		case 0:
+			if r != nil {
+				r.Delete(key)
+			}
+		case 1:
+			if value, data, err = readLengthPrefixed(data); err != nil {
+				return err
+			}
+			if r != nil {
+				r.Put(key, value)
+			}
+		default:
+			return ErrBatchCorrupt
+		}
+	}
+	if count != 0 {
+		return ErrBatchCorrupt
+	}
+	return nil
 }
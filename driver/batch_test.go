@@ -0,0 +1,60 @@
+package driver
+
+import (
+	"fmt"
+	"testing"
+)
+
+type recordingReplay struct {
+	updates []string
+}
+
+func (r *recordingReplay) Put(key, value []byte) {
+	r.updates = append(r.updates, fmt.Sprintf("put %s %s", key, value))
+}
+
+func (r *recordingReplay) Delete(key []byte) {
+	r.updates = append(r.updates, fmt.Sprintf("delete %s", key))
+}
+
+func batchData(count uint32, records ...byte) []byte {
+	data := make([]byte, BatchHeaderSize, BatchHeaderSize+len(records))
+	data[8], data[9], data[10], data[11] = byte(count), byte(count>>8), byte(count>>16), byte(count>>24)
+	return append(data, records...)
+}
+
+func TestReplayBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		updates []string
+	}{
+		{"empty", batchData(0), []string{}},
+		{"put and delete", batchData(2, 1, 1, 'a', 2, 'v', '1', 0, 1, 'b'), []string{"put a v1", "delete b"}},
+		{"empty key and value", batchData(1, 1, 0, 0), []string{"put  "}},
+		{"short header", batchData(0)[:BatchHeaderSize-1], nil},
+		{"truncated key", batchData(2, 0, 1, 'a', 1, 3, 'k'), nil},
+		{"truncated value", batchData(2, 0, 1, 'a', 1, 1, 'k', 4, 'v'), nil},
+		{"truncated length", batchData(2, 0, 1, 'a', 1, 1, 'k', 0x80), nil},
+		{"missing key", batchData(2, 0, 1, 'a', 1), nil},
+		{"bad kind", batchData(2, 0, 1, 'a', 2, 1, 'k'), nil},
+		{"count too large", batchData(3, 0, 1, 'a', 0, 1, 'b'), nil},
+		{"count too small", batchData(1, 0, 1, 'a', 0, 1, 'b'), nil},
+		{"trailing garbage", batchData(1, 0, 1, 'a', 0xff), nil},
+	}
+	for _, test := range tests {
+		var r recordingReplay
+		r.updates = []string{}
+		err := ReplayBatch(test.data, &r)
+		switch {
+		case test.updates == nil && err != ErrBatchCorrupt:
+			t.Errorf("%s: got error %v, want %v", test.name, err, ErrBatchCorrupt)
+		case test.updates == nil && len(r.updates) != 0:
+			t.Errorf("%s: got updates %q from corrupted batch", test.name, r.updates)
+		case test.updates != nil && err != nil:
+			t.Errorf("%s: got error %v", test.name, err)
+		case test.updates != nil && fmt.Sprint(r.updates) != fmt.Sprint(test.updates):
+			t.Errorf("%s: got updates %q, want %q", test.name, r.updates, test.updates)
+		}
+	}
+}
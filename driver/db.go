@@ -3,6 +3,10 @@ package driver
 type DB interface {
 	Get(key []byte, opts *ReadOptions) ([]byte, error)
 
+	// MultiGet gets values of keys, errs[i] is error of keys[i] as if got
+	// by Get.
+	MultiGet(keys [][]byte, opts *ReadOptions) (values [][]byte, errs []error)
+
 	Put(key, value []byte, opts *WriteOptions) error
 	Delete(key []byte, opts *WriteOptions) error
 	Write(batch Batch, opts *WriteOptions) error
@@ -16,3 +20,18 @@ type DB interface {
 
 	Batch() Batch
 }
+
+// IntoGetter is an optional interface of DB to read value into buffer of
+// caller, saving allocation of value.
+type IntoGetter interface {
+	// GetInto appends value of key to dst and returns the extended buffer.
+	GetInto(dst, key []byte, opts *ReadOptions) ([]byte, error)
+}
+
+// Compacter is an optional interface of DB to compact keys manually.
+type Compacter interface {
+	// CompactRange flushes memtable overlapping keys in [start, limit], and
+	// compacts these keys to max level they reside in. Nil start and limit
+	// stand for all keys.
+	CompactRange(start, limit []byte) error
+}
@@ -0,0 +1,39 @@
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var dbFilePattern = regexp.MustCompile(`^(CURRENT(\.bak|\.[0-9]+)?|LOCK|LOG|LOG\.old|MANIFEST-[0-9]+|[0-9]+\.(log|ldb|sst|tmp|dbtmp))$`)
+
+// IsDBFile reports whether name is name of file created by leveldb.
+func IsDBFile(name string) bool {
+	return dbFilePattern.MatchString(name)
+}
+
+// DestroyDir removes files of db in dir, and dir itself if it becomes empty.
+// Files not belonging to db are left untouched. It is intended for drivers
+// which have no native destroy.
+func DestroyDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !IsDBFile(f.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+			return err
+		}
+	}
+	// Like leveldb, ignore error as dir may contain other files.
+	os.Remove(dir)
+	return nil
+}
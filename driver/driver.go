@@ -4,6 +4,24 @@ import "fmt"
 
 type Driver interface {
 	Open(dir string, opts *Options) (DB, error)
+
+	// Destroy removes db in dir. Be very careful using this.
+	Destroy(dir string, opts *Options) error
+
+	// Repair tries to recover as much data as possible from a corrupted db
+	// in dir.
+	Repair(dir string, opts *Options) error
+}
+
+// UnsupportedOperationError reports an operation which is not supported by
+// driver.
+type UnsupportedOperationError struct {
+	Driver    string
+	Operation string
+}
+
+func (e *UnsupportedOperationError) Error() string {
+	return fmt.Sprintf("%s: operation %s is unsupported by this driver", e.Driver, e.Operation)
 }
 
 var drivers = map[string]Driver{}
@@ -15,10 +33,34 @@ func Register(name string, driver Driver) {
 	drivers[name] = driver
 }
 
-func Open(driverName, dbName string, opts *Options) (DB, error) {
+func lookup(driverName string) (Driver, error) {
 	driver := drivers[driverName]
 	if driver == nil {
 		return nil, fmt.Errorf("no driver for: %s", driverName)
 	}
+	return driver, nil
+}
+
+func Open(driverName, dbName string, opts *Options) (DB, error) {
+	driver, err := lookup(driverName)
+	if err != nil {
+		return nil, err
+	}
 	return driver.Open(dbName, opts)
 }
+
+func Destroy(driverName, dbName string, opts *Options) error {
+	driver, err := lookup(driverName)
+	if err != nil {
+		return err
+	}
+	return driver.Destroy(dbName, opts)
+}
+
+func Repair(driverName, dbName string, opts *Options) error {
+	driver, err := lookup(driverName)
+	if err != nil {
+		return err
+	}
+	return driver.Repair(dbName, opts)
+}
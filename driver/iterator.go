@@ -43,3 +43,11 @@ type Iterator interface {
 	// call any methods after this iterator has been closed.
 	Close() error
 }
+
+// UnsafeIterator is an optional interface of Iterator to access current entry
+// without copying. Returned slices must not be modified, and are valid only
+// until next call to any methods of iterator.
+type UnsafeIterator interface {
+	KeyUnsafe() []byte
+	ValueUnsafe() []byte
+}
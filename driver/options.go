@@ -1,19 +1,56 @@
 package driver
 
+import "fmt"
+
 const (
 	DefaultCompression = iota
 	NoCompression
 	SnappyCompression
 )
 
+// Comparer defines a total order over keys.
+type Comparer struct {
+	// Name identifies the order. A db created with one comparer can't be
+	// opened with comparer of different name.
+	Name string
+
+	// Compare returns a value 'less than', 'equal to' or 'greater than' 0
+	// depending on whether a is 'less than', 'equal to' or 'greater than' b.
+	Compare func(a, b []byte) int
+}
+
 type Options struct {
-	Compression        int
-	MaxOpenFiles       int
-	BloomBitsPerKey    int
-	WriteBufferSize    int
-	BlockCacheCapacity int
-	CreateIfMissing    bool
-	ErrorIfExists      bool
+	Comparer             *Comparer
+	Compression          int
+	MaxOpenFiles         int
+	BloomBitsPerKey      int
+	WriteBufferSize      int
+	BlockCacheCapacity   int
+	BlockSize            int
+	BlockRestartInterval int
+	MaxFileSize          int
+	ParanoidChecks       bool
+	ReuseLogs            bool
+	Storage              int
+	StorageStats         *StorageStats
+	CreateIfMissing      bool
+	ErrorIfExists        bool
+
+	// ReplayableBatches makes batches support Replay, and so EncodeBatch,
+	// in drivers which must record a copy of updates for it. Other drivers
+	// ignore it.
+	ReplayableBatches bool
+}
+
+// UnsupportedOptionError reports an option which is set but not supported
+// by driver.
+type UnsupportedOptionError struct {
+	Driver string
+	Option string
+}
+
+func (e *UnsupportedOptionError) Error() string {
+	return fmt.Sprintf("%s: option %s is unsupported by this driver", e.Driver, e.Option)
 }
 
 type ReadOptions struct {
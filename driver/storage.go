@@ -0,0 +1,43 @@
+package driver
+
+import "sync/atomic"
+
+const (
+	OSStorage = iota
+	MemoryStorage
+)
+
+// StorageStats counts file operations of db if set in Options. Fields are
+// updated atomically.
+type StorageStats struct {
+	Reads      int64
+	ReadBytes  int64
+	Writes     int64
+	WriteBytes int64
+	Syncs      int64
+}
+
+func (s *StorageStats) CountRead(n int) {
+	atomic.AddInt64(&s.Reads, 1)
+	atomic.AddInt64(&s.ReadBytes, int64(n))
+}
+
+func (s *StorageStats) CountWrite(n int) {
+	atomic.AddInt64(&s.Writes, 1)
+	atomic.AddInt64(&s.WriteBytes, int64(n))
+}
+
+func (s *StorageStats) CountSync() {
+	atomic.AddInt64(&s.Syncs, 1)
+}
+
+// Load returns a snapshot of s.
+func (s *StorageStats) Load() StorageStats {
+	return StorageStats{
+		Reads:      atomic.LoadInt64(&s.Reads),
+		ReadBytes:  atomic.LoadInt64(&s.ReadBytes),
+		Writes:     atomic.LoadInt64(&s.Writes),
+		WriteBytes: atomic.LoadInt64(&s.WriteBytes),
+		Syncs:      atomic.LoadInt64(&s.Syncs),
+	}
+}
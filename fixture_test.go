@@ -0,0 +1,106 @@
+package leveldb_test
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var fixtureDir = flag.String("fixture_dir", "", "Directory to cache prebuilt dbs across runs")
+
+// prepareDB returns directory of a private db with n entries. If -fixture_dir
+// is set, the db is cloned from a cached fixture which is built on first use.
+func prepareDB(n int) string {
+	if *fixtureDir == "" {
+		return newDB("", n)
+	}
+	return cloneDB(fixtureDB(n))
+}
+
+// valueGeneratorVersion is bumped when generated values change, so fixtures
+// of old values are not reused.
+const valueGeneratorVersion = 2
+
+// fixtureName identifies content of db with n entries. Dbs with same name are
+// built from same keys, values and options.
+func fixtureName(n int) string {
+	h := fnv.New64a()
+	opts := createOptions
+	if opts.Comparer != nil {
+		fmt.Fprintf(h, "%s/", opts.Comparer.Name)
+		opts.Comparer = nil
+	}
+	opts.StorageStats = nil
+	fmt.Fprintf(h, "%+v/%s/%s/%g/%d", opts, keysName(), valueSizesName(), *compressionRatio, valueGeneratorVersion)
+	return fmt.Sprintf("%s-%d-%d-%016x", *driverName, n, randSeed, h.Sum64())
+}
+
+func fixtureDB(n int) string {
+	dir := filepath.Join(*fixtureDir, fixtureName(n))
+	if _, err := os.Stat(dir); err == nil {
+		return dir
+	}
+	if err := os.MkdirAll(*fixtureDir, 0755); err != nil {
+		panic(fmt.Errorf("fixture dir create error: %s", err))
+	}
+	tmp := newDB(*fixtureDir, n)
+	if err := os.Rename(tmp, dir); err != nil {
+		destroyDB(tmp)
+		// Concurrent run may have built same fixture.
+		if _, serr := os.Stat(dir); serr != nil {
+			panic(fmt.Errorf("fixture %q install error: %s", dir, err))
+		}
+	}
+	return dir
+}
+
+// cloneDB clones db in src to a new temporary directory. Table files are
+// immutable once written, so they are hard linked if possible.
+func cloneDB(src string) string {
+	dst, err := ioutil.TempDir("", "leveldb-benchmark-")
+	if err != nil {
+		panic(fmt.Errorf("temp dir create error: %s", err))
+	}
+	files, err := ioutil.ReadDir(src)
+	if err != nil {
+		os.RemoveAll(dst)
+		panic(fmt.Errorf("fixture %q read error: %s", src, err))
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		from, to := filepath.Join(src, f.Name()), filepath.Join(dst, f.Name())
+		if ext := filepath.Ext(f.Name()); ext == ".ldb" || ext == ".sst" {
+			if os.Link(from, to) == nil {
+				continue
+			}
+		}
+		if err := copyFile(from, to); err != nil {
+			os.RemoveAll(dst)
+			panic(fmt.Errorf("fixture %q clone error: %s", src, err))
+		}
+	}
+	return dst
+}
+
+func copyFile(from, to string) error {
+	r, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package leveldb_test
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var gcStats = flag.Bool("gc_stats", false, "Report gc cycles, pauses and allocations of benchmark")
+var gogc = flag.String("gogc", "", "Comma separated GOGC values to run benchmarks with in turn, e.g. 50,100,off")
+
+type gogcValue struct {
+	name    string
+	percent int
+}
+
+var gogcValues []gogcValue
+
+// currentGOGC is GOGC value of running benchmarks in -gogc sweep.
+var currentGOGC string
+
+func initGC() error {
+	if *gogc == "" {
+		return nil
+	}
+	for _, s := range strings.Split(*gogc, ",") {
+		s = strings.TrimSpace(s)
+		percent := -1
+		if s != "off" {
+			var err error
+			if percent, err = strconv.Atoi(s); err != nil || percent < 0 {
+				return fmt.Errorf("invalid gogc value: %q", s)
+			}
+		}
+		gogcValues = append(gogcValues, gogcValue{name: s, percent: percent})
+	}
+	return nil
+}
+
+// runGOGCSweep runs m once for each -gogc value, or once if -gogc is not set.
+// Each run is preceded by a "gogc: value" configuration line, which is
+// understood by benchstat.
+func runGOGCSweep(m *testing.M) int {
+	if len(gogcValues) == 0 {
+		return m.Run()
+	}
+	code := 0
+	for _, v := range gogcValues {
+		currentGOGC = v.name
+		old := debug.SetGCPercent(v.percent)
+		fmt.Printf("gogc: %s\n", v.name)
+		if c := m.Run(); c != 0 {
+			code = c
+		}
+		debug.SetGCPercent(old)
+	}
+	currentGOGC = ""
+	return code
+}
+
+func sampleGCStats() *runtime.MemStats {
+	if !*gcStats {
+		return nil
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return &ms
+}
+
+// reportGCStats reports gc cycles, pauses and allocations since start of run.
+func (run *benchmarkRun) reportGCStats() {
+	start, end := run.gc, sampleGCStats()
+	if start == nil || end == nil {
+		return
+	}
+	var maxPause uint64
+	pauses := uint32(len(end.PauseNs))
+	for i := end.NumGC; i > start.NumGC && end.NumGC-i < pauses; i-- {
+		// Pause of i-th gc is in circular buffer of recent pauses.
+		if pause := end.PauseNs[(i+pauses-1)%pauses]; pause > maxPause {
+			maxPause = pause
+		}
+	}
+	n := float64(run.b.N)
+	run.reportMetric(float64(end.NumGC-start.NumGC), "gcs")
+	run.reportMetric(float64(end.PauseTotalNs-start.PauseTotalNs), "gc-pause-ns")
+	run.reportMetric(float64(maxPause), "gc-max-pause-ns")
+	run.reportMetric(float64(end.Mallocs-start.Mallocs)/n, "mallocs/op")
+	run.reportMetric(float64(end.TotalAlloc-start.TotalAlloc)/n, "alloc-B/op")
+}
@@ -1,8 +1,10 @@
 module github.com/kezhuw/go-leveldb-benchmarks
 
-go 1.12
+go 1.20
 
 require (
 	github.com/kezhuw/leveldb v0.0.0-20200221131720-39763608f29b
 	github.com/syndtr/goleveldb v1.0.0
 )
+
+require github.com/golang/snappy v0.0.1 // indirect
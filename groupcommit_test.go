@@ -0,0 +1,119 @@
+package leveldb_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/cgo"
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+// openSyncCountedDB creates an empty db, and returns function counting syncs
+// of db. Syncs are counted by storageStats if driver supports, otherwise by
+// syncs from C code if counted. syncs is nil if neither is available.
+func openSyncCountedDB(b testing.TB) (db driver.DB, syncs func() int64, cleanup func()) {
+	dir, err := ioutil.TempDir("", "leveldb-benchmark-")
+	if err != nil {
+		b.Fatalf("temp dir create error: %s", err)
+	}
+	opts := createOptions
+	opts.StorageStats = &storageStats
+	syncs = func() int64 { return storageStats.Load().Syncs }
+	db, err = driver.Open(*driverName, dir, &opts)
+	var unsupported *driver.UnsupportedOptionError
+	if errors.As(err, &unsupported) && unsupported.Option == "StorageStats" {
+		opts.StorageStats = nil
+		syncs = nil
+		if _, ok := cgo.Syncs(); ok {
+			syncs = func() int64 {
+				n, _ := cgo.Syncs()
+				return n
+			}
+		}
+		db, err = driver.Open(*driverName, dir, &opts)
+	}
+	if err != nil {
+		destroyDB(dir)
+		b.Fatalf("create db %q error: %s", dir, err)
+	}
+	return db, syncs, func() { db.Close(); destroyDB(dir) }
+}
+
+func buildSyncWrite(parallelism int) func(*testing.B) {
+	return func(b *testing.B) {
+		db, syncs, cleanup := openSyncCountedDB(b)
+		defer cleanup()
+		if syncs == nil {
+			b.Logf("syncs of driver %s are not counted, syncs/op is not reported", *driverName)
+		}
+		if runtime.GOMAXPROCS(0) == 1 {
+			b.Log("GOMAXPROCS is 1, writers hardly queue while one syncs, so writes are barely grouped")
+		}
+		opts := driver.WriteOptions{Sync: true}
+		g := newFullRandomEntryGenerator(0, (b.N+parallelism-1)/parallelism*parallelism)
+		var base int64
+		if syncs != nil {
+			base = syncs()
+		}
+		writes := float64(runConcurrently(b, parallelism, func(start, n, workers int) error {
+			return runOps(n, workers, func() (func(int) error, func()) {
+				return func(i int) error {
+					return db.Put(g.Key(start+i), g.Value(start+i), &opts)
+				}, nil
+			})
+		}))
+		if seconds := b.Elapsed().Seconds(); seconds > 0 {
+			reportMetric(b, writes/seconds, "put/s")
+		}
+		// With -storage_stats, syncs/op is reported along with other stats.
+		if syncs != nil && !*storageStatsEnabled {
+			reportMetric(b, float64(syncs()-base)/writes, "syncs/op")
+		}
+	}
+}
+
+// BenchmarkSyncWrite sweeps goroutines writing random entries with sync, and
+// reports syncs per write to show how well writes are grouped in commits.
+func BenchmarkSyncWrite(b *testing.B) {
+	runParallelismSweep(b, buildSyncWrite)
+}
+
+// TestSyncsObserveGroupCommit checks that syncs counted for BenchmarkSyncWrite
+// drop below one per write when many goroutines write with sync, as writes
+// queued during a sync are committed by next one.
+func TestSyncsObserveGroupCommit(t *testing.T) {
+	const goroutines, writes = 64, 16
+	db, syncs, cleanup := openSyncCountedDB(t)
+	defer cleanup()
+	if syncs == nil {
+		t.Skipf("syncs of driver %s are not counted", *driverName)
+	}
+	// Writers queue only if others run while one is in sync.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	g := newFullRandomEntryGenerator(0, goroutines*writes)
+	opts := driver.WriteOptions{Sync: true}
+	start := syncs()
+	errs := make([]error, goroutines)
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for j := i * writes; j < (i+1)*writes && errs[i] == nil; j++ {
+				errs[i] = db.Put(g.Key(j), g.Value(j), &opts)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := syncs() - start; n == 0 || n >= goroutines*writes/2 {
+		t.Errorf("got %d syncs for %d writes, want some but fewer than half", n, goroutines*writes)
+	}
+}
@@ -0,0 +1,232 @@
+package leveldb_test
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+var keySchemaName = flag.String("key_schema", "decimal", "Schema of keys: decimal, binary, composite, uuid or ulid")
+var keyLenMin = flag.Int("key_len", 0, "Min key length, shorter keys are padded, 0 for natural length of schema")
+var keyLenMax = flag.Int("key_len_max", 0, "Max key length, key lengths vary between key_len and key_len_max if set")
+var keyPrefixes = flag.Int("key_prefixes", 16, "Number of distinct prefixes in composite keys")
+
+// keySchema builds keys from integer ids. Keys of distinct ids are distinct,
+// and missing keys never collide with hit keys.
+type keySchema interface {
+	// AppendKey appends key of id to dst and returns the extended buffer.
+	AppendKey(dst []byte, id int, missing bool) []byte
+
+	// MaxLen returns max length of keys.
+	MaxLen() int
+}
+
+var keyspace keySchema
+
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func keyHash(id int, missing bool) uint64 {
+	x := uint64(id) << 1
+	if missing {
+		x |= 1
+	}
+	return mix64(x)
+}
+
+func appendPadded(dst []byte, v uint64, width int) []byte {
+	var buf [20]byte
+	b := strconv.AppendUint(buf[:0], v, 10)
+	for i := len(b); i < width; i++ {
+		dst = append(dst, '0')
+	}
+	return append(dst, b...)
+}
+
+const decimalKeyWidth = 16
+
+// decimalKeySchema builds keys as "%016d+" for hits and "%016d-" for misses.
+type decimalKeySchema struct{}
+
+func (decimalKeySchema) AppendKey(dst []byte, id int, missing bool) []byte {
+	dst = appendPadded(dst, uint64(id), decimalKeyWidth)
+	if missing {
+		return append(dst, '-')
+	}
+	return append(dst, '+')
+}
+
+func (decimalKeySchema) MaxLen() int {
+	return decimalKeyWidth + 1
+}
+
+// binaryKeySchema builds keys as big endian id followed by 0x00 for hits
+// and 0xff for misses.
+type binaryKeySchema struct{}
+
+func (binaryKeySchema) AppendKey(dst []byte, id int, missing bool) []byte {
+	var buf [9]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(id))
+	if missing {
+		buf[8] = 0xff
+	}
+	return append(dst, buf[:]...)
+}
+
+func (binaryKeySchema) MaxLen() int {
+	return 9
+}
+
+// compositeKeySchema builds keys as "prefix/id/suffix", where prefix is one
+// of a fixed number of tenants.
+type compositeKeySchema struct {
+	prefixes int
+	width    int
+}
+
+func newCompositeKeySchema(prefixes int) compositeKeySchema {
+	return compositeKeySchema{prefixes: prefixes, width: len(strconv.Itoa(prefixes - 1))}
+}
+
+func (s compositeKeySchema) AppendKey(dst []byte, id int, missing bool) []byte {
+	dst = append(dst, 't')
+	dst = appendPadded(dst, uint64(id%s.prefixes), s.width)
+	dst = append(dst, '/')
+	dst = appendPadded(dst, uint64(id), decimalKeyWidth)
+	if missing {
+		return append(dst, "/miss"...)
+	}
+	return append(dst, "/data"...)
+}
+
+func (s compositeKeySchema) MaxLen() int {
+	return 1 + s.width + 1 + decimalKeyWidth + 5
+}
+
+const hexDigits = "0123456789abcdef"
+
+// uuidKeySchema builds keys as random version 4 uuid strings.
+type uuidKeySchema struct{}
+
+func (uuidKeySchema) AppendKey(dst []byte, id int, missing bool) []byte {
+	var u [16]byte
+	hi := keyHash(id, missing)
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], mix64(hi))
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	for i, c := range u {
+		switch i {
+		case 4, 6, 8, 10:
+			dst = append(dst, '-')
+		}
+		dst = append(dst, hexDigits[c>>4], hexDigits[c&0x0f])
+	}
+	return dst
+}
+
+func (uuidKeySchema) MaxLen() int {
+	return 36
+}
+
+const crockfordDigits = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidEpoch is timestamp of id 0 in ulid keys, 2020-01-01 in milliseconds.
+const ulidEpoch = 1577836800000
+
+// ulidKeySchema builds keys as ulid strings. Id n has timestamp n
+// milliseconds after ulidEpoch, so keys are ordered by id.
+type ulidKeySchema struct{}
+
+func (ulidKeySchema) AppendKey(dst []byte, id int, missing bool) []byte {
+	random := keyHash(id, missing)
+	hi := (ulidEpoch+uint64(id))<<16 | random>>48
+	lo := mix64(random)
+	for pos := 125; pos >= 0; pos -= 5 {
+		var v uint64
+		switch {
+		case pos >= 64:
+			v = hi >> uint(pos-64)
+		case pos > 59:
+			v = lo>>uint(pos) | hi<<uint(64-pos)
+		default:
+			v = lo >> uint(pos)
+		}
+		dst = append(dst, crockfordDigits[v&31])
+	}
+	return dst
+}
+
+func (ulidKeySchema) MaxLen() int {
+	return 26
+}
+
+// paddedKeySchema pads keys to lengths between min and max, length of each
+// key is chosen by its id.
+type paddedKeySchema struct {
+	keySchema
+	min, max int
+	binary   bool
+}
+
+func (s paddedKeySchema) AppendKey(dst []byte, id int, missing bool) []byte {
+	begin := len(dst)
+	dst = s.keySchema.AppendKey(dst, id, missing)
+	n := s.min
+	if s.max > s.min {
+		n += int(keyHash(id, missing) % uint64(s.max-s.min+1))
+	}
+	for h := mix64(uint64(id)); len(dst)-begin < n; h = mix64(h) {
+		if s.binary {
+			dst = append(dst, byte(h))
+		} else {
+			dst = append(dst, 'a'+byte(h%26))
+		}
+	}
+	return dst
+}
+
+func (s paddedKeySchema) MaxLen() int {
+	return maxInt(s.keySchema.MaxLen(), s.max)
+}
+
+func newKeySchema() (keySchema, error) {
+	var schema keySchema
+	switch *keySchemaName {
+	case "decimal":
+		schema = decimalKeySchema{}
+	case "binary":
+		schema = binaryKeySchema{}
+	case "composite":
+		if *keyPrefixes <= 0 {
+			return nil, fmt.Errorf("invalid key_prefixes: %d", *keyPrefixes)
+		}
+		schema = newCompositeKeySchema(*keyPrefixes)
+	case "uuid":
+		schema = uuidKeySchema{}
+	case "ulid":
+		schema = ulidKeySchema{}
+	default:
+		return nil, fmt.Errorf("unknown key schema: %s", *keySchemaName)
+	}
+	if *keyLenMin <= 0 && *keyLenMax <= 0 {
+		return schema, nil
+	}
+	min, max := *keyLenMin, maxInt(*keyLenMax, *keyLenMin)
+	return paddedKeySchema{keySchema: schema, min: min, max: max, binary: *keySchemaName == "binary"}, nil
+}
+
+func initKeySchema() (err error) {
+	keyspace, err = newKeySchema()
+	return err
+}
+
+// keysName describes keys for fixture naming.
+func keysName() string {
+	return fmt.Sprintf("%s:%d:%d:%d", *keySchemaName, *keyLenMin, *keyLenMax, *keyPrefixes)
+}
@@ -0,0 +1,89 @@
+package kezhuw
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+	"github.com/kezhuw/leveldb"
+)
+
+var errBatchNotReplayable = errors.New("kezhuw: batch replay requires option ReplayableBatches")
+
+// batch counts updates and size in Go, as leveldb.Batch exposes no content.
+// With option ReplayableBatches, it also keeps a shadow of updates in LevelDB
+// representation for Replay, which copies every update once more. Count in
+// header of shadow is filled on replay.
+type batch struct {
+	leveldb.Batch
+	count      int
+	size       int
+	replayable bool
+	rep        []byte
+}
+
+func uvarintSize(n int) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], uint64(n))
+}
+
+func (b *batch) appendLengthPrefixed(p []byte) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(p)))
+	b.rep = append(b.rep, buf[:n]...)
+	b.rep = append(b.rep, p...)
+}
+
+func (b *batch) appendKind(kind byte) {
+	if len(b.rep) == 0 {
+		b.rep = append(b.rep, make([]byte, driver.BatchHeaderSize)...)
+	}
+	b.rep = append(b.rep, kind)
+}
+
+func (b *batch) Put(key, value []byte) {
+	b.Batch.Put(key, value)
+	b.count++
+	b.size += 1 + uvarintSize(len(key)) + len(key) + uvarintSize(len(value)) + len(value)
+	if b.replayable {
+		b.appendKind(1)
+		b.appendLengthPrefixed(key)
+		b.appendLengthPrefixed(value)
+	}
+}
+
+func (b *batch) Delete(key []byte) {
+	b.Batch.Delete(key)
+	b.count++
+	b.size += 1 + uvarintSize(len(key)) + len(key)
+	if b.replayable {
+		b.appendKind(0)
+		b.appendLengthPrefixed(key)
+	}
+}
+
+func (b *batch) Clear() {
+	b.Batch.Clear()
+	b.count = 0
+	b.size = 0
+	b.rep = b.rep[:0]
+}
+
+func (b *batch) Len() int {
+	return b.count
+}
+
+func (b *batch) ByteSize() int {
+	return driver.BatchHeaderSize + b.size
+}
+
+func (b *batch) Replay(r driver.BatchReplay) error {
+	if !b.replayable {
+		return errBatchNotReplayable
+	}
+	if b.count == 0 {
+		return nil
+	}
+	binary.LittleEndian.PutUint32(b.rep[8:], uint32(b.count))
+	return driver.ReplayBatch(b.rep, r)
+}
@@ -6,13 +6,24 @@ import (
 )
 
 type DB struct {
-	db *leveldb.DB
+	db                *leveldb.DB
+	replayableBatches bool
 }
 
 func (db *DB) Get(key []byte, opts *driver.ReadOptions) ([]byte, error) {
 	return db.db.Get(key, convertReadOptions(opts))
 }
 
+func (db *DB) MultiGet(keys [][]byte, opts *driver.ReadOptions) ([][]byte, []error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	ropts := convertReadOptions(opts)
+	for i, key := range keys {
+		values[i], errs[i] = db.db.Get(key, ropts)
+	}
+	return values, errs
+}
+
 func (db *DB) Put(key, value []byte, opts *driver.WriteOptions) error {
 	return db.db.Put(key, value, convertWriteOptions(opts))
 }
@@ -21,12 +32,12 @@ func (db *DB) Delete(key []byte, opts *driver.WriteOptions) error {
 	return db.db.Delete(key, convertWriteOptions(opts))
 }
 
-func (db *DB) Write(batch driver.Batch, opts *driver.WriteOptions) error {
-	return db.db.Write(*(batch.(*leveldb.Batch)), convertWriteOptions(opts))
+func (db *DB) Write(writes driver.Batch, opts *driver.WriteOptions) error {
+	return db.db.Write(writes.(*batch).Batch, convertWriteOptions(opts))
 }
 
 func (db *DB) Batch() driver.Batch {
-	return new(leveldb.Batch)
+	return &batch{replayable: db.replayableBatches}
 }
 
 func (db *DB) Close() error {
@@ -41,20 +52,64 @@ func (db *DB) IsCorrupt(err error) bool {
 	return leveldb.IsCorrupt(err)
 }
 
+func (db *DB) CompactRange(start, limit []byte) error {
+	return db.db.CompactRange(start, limit)
+}
+
 func (db *DB) All(opts *driver.ReadOptions) driver.Iterator {
 	return db.db.All(convertReadOptions(opts))
 }
 
-func convertOptions(dopts *driver.Options) *leveldb.Options {
+type comparator struct {
+	c *driver.Comparer
+}
+
+func (c comparator) Name() string {
+	return c.c.Name
+}
+
+func (c comparator) Compare(a, b []byte) int {
+	return c.c.Compare(a, b)
+}
+
+// AppendSuccessor appends start to dst, as we know nothing about the order.
+func (c comparator) AppendSuccessor(dst, start, limit []byte) []byte {
+	return append(dst, start...)
+}
+
+// MakePrefixSuccessor is used by prefix iteration only, which this driver
+// does not expose.
+func (c comparator) MakePrefixSuccessor(prefix []byte) []byte {
+	return nil
+}
+
+func convertOptions(dopts *driver.Options) (*leveldb.Options, error) {
 	if dopts == nil {
-		return nil
+		return nil, nil
+	}
+	switch {
+	case dopts.ParanoidChecks:
+		return nil, &driver.UnsupportedOptionError{Driver: "kezhuw", Option: "ParanoidChecks"}
+	case dopts.ReuseLogs:
+		return nil, &driver.UnsupportedOptionError{Driver: "kezhuw", Option: "ReuseLogs"}
 	}
 	opts := &leveldb.Options{
-		CreateIfMissing:    dopts.CreateIfMissing,
-		ErrorIfExists:      dopts.ErrorIfExists,
-		BlockCacheCapacity: dopts.BlockCacheCapacity,
-		MaxOpenFiles:       dopts.MaxOpenFiles,
-		WriteBufferSize:    dopts.WriteBufferSize,
+		CreateIfMissing:      dopts.CreateIfMissing,
+		ErrorIfExists:        dopts.ErrorIfExists,
+		BlockCacheCapacity:   dopts.BlockCacheCapacity,
+		MaxOpenFiles:         dopts.MaxOpenFiles,
+		WriteBufferSize:      dopts.WriteBufferSize,
+		BlockSize:            dopts.BlockSize,
+		BlockRestartInterval: dopts.BlockRestartInterval,
+		MaxFileSize:          int64(dopts.MaxFileSize),
+	}
+	fs, err := convertStorage(dopts)
+	if err != nil {
+		return nil, err
+	}
+	opts.FileSystem = fs
+	if dopts.Comparer != nil {
+		opts.Comparator = comparator{dopts.Comparer}
 	}
 	if dopts.BloomBitsPerKey > 0 {
 		opts.Filter = leveldb.NewBloomFilter(dopts.BloomBitsPerKey)
@@ -65,7 +120,7 @@ func convertOptions(dopts *driver.Options) *leveldb.Options {
 	case driver.SnappyCompression:
 		opts.Compression = leveldb.SnappyCompression
 	}
-	return opts
+	return opts, nil
 }
 
 func convertReadOptions(opts *driver.ReadOptions) *leveldb.ReadOptions {
@@ -80,11 +135,27 @@ type driverType struct {
 }
 
 func (driverType) Open(dir string, opts *driver.Options) (driver.DB, error) {
-	db, err := leveldb.Open(dir, convertOptions(opts))
+	options, err := convertOptions(opts)
 	if err != nil {
 		return nil, err
 	}
-	return &DB{db}, nil
+	db, err := leveldb.Open(dir, options)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{db: db, replayableBatches: opts != nil && opts.ReplayableBatches}, nil
+}
+
+func (driverType) Destroy(dir string, opts *driver.Options) error {
+	if opts != nil && opts.Storage == driver.MemoryStorage {
+		destroyMemDB(dir)
+		return nil
+	}
+	return driver.DestroyDir(dir)
+}
+
+func (driverType) Repair(dir string, opts *driver.Options) error {
+	return &driver.UnsupportedOperationError{Driver: "kezhuw", Operation: "Repair"}
 }
 
 func init() {
@@ -0,0 +1,310 @@
+package kezhuw
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+	"github.com/kezhuw/leveldb"
+)
+
+// memFileSystem is a hierarchical file storage in memory. Files live until
+// removed, so dbs in it can be reopened by name.
+type memFileSystem struct {
+	mu     sync.Mutex
+	files  map[string]*memFileData
+	dirs   map[string]bool
+	locked map[string]bool
+}
+
+var memFS = &memFileSystem{
+	files:  make(map[string]*memFileData),
+	dirs:   make(map[string]bool),
+	locked: make(map[string]bool),
+}
+
+type memFileData struct {
+	mu   sync.RWMutex
+	data []byte
+}
+
+func (fs *memFileSystem) Open(name string, flag int) (leveldb.File, error) {
+	name = filepath.Clean(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	data := fs.files[name]
+	switch {
+	case data != nil && flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+	case data == nil && flag&os.O_CREATE == 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case data == nil:
+		data = &memFileData{}
+		fs.files[name] = data
+	case flag&os.O_TRUNC != 0:
+		data.mu.Lock()
+		data.data = nil
+		data.mu.Unlock()
+	}
+	return &memFile{memFileData: data, flag: flag}, nil
+}
+
+type memLocker struct {
+	fs   *memFileSystem
+	name string
+}
+
+func (l memLocker) Close() error {
+	l.fs.mu.Lock()
+	delete(l.fs.locked, l.name)
+	l.fs.mu.Unlock()
+	return nil
+}
+
+func (fs *memFileSystem) Lock(name string) (io.Closer, error) {
+	name = filepath.Clean(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.locked[name] {
+		return nil, errors.New("memfs: " + name + " already locked")
+	}
+	fs.locked[name] = true
+	if fs.files[name] == nil {
+		fs.files[name] = &memFileData{}
+	}
+	return memLocker{fs: fs, name: name}, nil
+}
+
+func (fs *memFileSystem) Exists(name string) bool {
+	name = filepath.Clean(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.files[name] != nil || fs.dirs[name]
+}
+
+func (fs *memFileSystem) MkdirAll(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for path = filepath.Clean(path); !fs.dirs[path]; path = filepath.Dir(path) {
+		fs.dirs[path] = true
+	}
+	return nil
+}
+
+func (fs *memFileSystem) List(dir string) ([]string, error) {
+	dir = filepath.Clean(dir)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if !fs.dirs[dir] {
+		return nil, &os.PathError{Op: "open", Path: dir, Err: os.ErrNotExist}
+	}
+	var names []string
+	for name := range fs.files {
+		if filepath.Dir(name) == dir {
+			names = append(names, filepath.Base(name))
+		}
+	}
+	for name := range fs.dirs {
+		if name != dir && filepath.Dir(name) == dir {
+			names = append(names, filepath.Base(name))
+		}
+	}
+	return names, nil
+}
+
+func (fs *memFileSystem) Remove(name string) error {
+	name = filepath.Clean(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	switch {
+	case fs.files[name] != nil:
+		delete(fs.files, name)
+	case fs.dirs[name]:
+		for other := range fs.files {
+			if filepath.Dir(other) == name {
+				return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+			}
+		}
+		delete(fs.dirs, name)
+	default:
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+func (fs *memFileSystem) Rename(oldpath, newpath string) error {
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	data := fs.files[oldpath]
+	if data == nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	delete(fs.files, oldpath)
+	fs.files[newpath] = data
+	return nil
+}
+
+type memFile struct {
+	*memFileData
+	flag int
+	pos  int64
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.pos)
+	f.pos += int64(n)
+	if err == io.EOF && n != 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.flag&os.O_APPEND != 0 {
+		f.pos = int64(len(f.data))
+	}
+	if end := f.pos + int64(len(p)); end > int64(len(f.data)) {
+		if end > int64(cap(f.data)) {
+			data := make([]byte, end, 2*end)
+			copy(data, f.data)
+			f.data = data
+		} else if n := int64(len(f.data)); f.pos > n {
+			// Bytes between old end and pos may be stale ones of truncated
+			// data, zero them as file hole.
+			gap := f.data[n:f.pos]
+			for i := range gap {
+				gap[i] = 0
+			}
+		}
+		f.data = f.data[:end]
+	}
+	copy(f.data[f.pos:], p)
+	f.pos += int64(len(p))
+	return len(p), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		f.mu.RLock()
+		offset += int64(len(f.data))
+		f.mu.RUnlock()
+	}
+	if offset < 0 {
+		return f.pos, errors.New("memfs: negative position")
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if size <= int64(len(f.data)) {
+		f.data = f.data[:size]
+		return nil
+	}
+	f.data = append(f.data, make([]byte, size-int64(len(f.data)))...)
+	return nil
+}
+
+func (f *memFile) Sync() error {
+	return nil
+}
+
+func (f *memFile) Close() error {
+	return nil
+}
+
+// destroyMemDB removes files of db in dir from memFS.
+func destroyMemDB(dir string) {
+	names, _ := memFS.List(dir)
+	for _, name := range names {
+		if driver.IsDBFile(name) {
+			memFS.Remove(filepath.Join(dir, name))
+		}
+	}
+	memFS.Remove(dir)
+}
+
+// countingFileSystem counts file operations of underlying file system.
+type countingFileSystem struct {
+	leveldb.FileSystem
+	stats *driver.StorageStats
+}
+
+func (fs countingFileSystem) Open(name string, flag int) (leveldb.File, error) {
+	f, err := fs.FileSystem.Open(name, flag)
+	if err != nil {
+		return nil, err
+	}
+	return countingFile{File: f, stats: fs.stats}, nil
+}
+
+type countingFile struct {
+	leveldb.File
+	stats *driver.StorageStats
+}
+
+func (f countingFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.stats.CountRead(n)
+	return n, err
+}
+
+func (f countingFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+	f.stats.CountRead(n)
+	return n, err
+}
+
+func (f countingFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	f.stats.CountWrite(n)
+	return n, err
+}
+
+func (f countingFile) Sync() error {
+	f.stats.CountSync()
+	return f.File.Sync()
+}
+
+func convertStorage(dopts *driver.Options) (leveldb.FileSystem, error) {
+	var fs leveldb.FileSystem
+	switch dopts.Storage {
+	case driver.OSStorage:
+		if dopts.StorageStats == nil {
+			return nil, nil
+		}
+		fs = leveldb.DefaultFileSystem
+	case driver.MemoryStorage:
+		fs = memFS
+	default:
+		return nil, &driver.UnsupportedOptionError{Driver: "kezhuw", Option: "Storage"}
+	}
+	if dopts.StorageStats != nil {
+		fs = countingFileSystem{FileSystem: fs, stats: dopts.StorageStats}
+	}
+	return fs, nil
+}
@@ -0,0 +1,46 @@
+package leveldb_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+var largeValueSizes = []int{100 << 10, 1 << 20, 4 << 20}
+
+func buildFillLarge(valueSize int) func(*testing.B) {
+	return func(b *testing.B) {
+		db, cleanup := openEmptyDB(b)
+		defer cleanup()
+		keys := newSequentialKeyGenerator(b.N)
+		values := makeRandomValueGenerator(newRand(), *compressionRatio, valueSize)
+		resetBenchmark(b)
+		for i := 0; i < b.N; i++ {
+			if err := db.Put(keys.Key(i), values.Value(i), &writeOptions); err != nil {
+				b.Fatalf("db put key[%s] error: %s", keys.Key(i), err)
+			}
+		}
+		b.StopTimer()
+		if seconds := b.Elapsed().Seconds(); seconds > 0 {
+			reportMetric(b, float64(b.N)*float64(valueSize)/1e6/seconds, "MB/s")
+		}
+		get := newGetter(db)
+		for i := 0; i < b.N; i++ {
+			value, err := get.Get(keys.Key(i))
+			if err != nil {
+				b.Fatalf("db get key[%s] error: %s", keys.Key(i), err)
+			}
+			if !bytes.Equal(value, values.Value(i)) {
+				b.Fatalf("db get key[%s]: value mismatch", keys.Key(i))
+			}
+		}
+	}
+}
+
+// BenchmarkFillLarge writes values of 100KB to 4MB in order, which span
+// blocks and log records, and verifies them by reading back.
+func BenchmarkFillLarge(b *testing.B) {
+	for _, n := range largeValueSizes {
+		b.Run(fmt.Sprintf("value-%d", n), buildFillLarge(n))
+	}
+}
@@ -0,0 +1,110 @@
+package leveldb_test
+
+import (
+	"bufio"
+	"flag"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/cgo"
+)
+
+var memStats = flag.Bool("mem_stats", false, "Report peak rss, Go heap and C heap in use of benchmark, rss is linux only")
+
+const memSampleInterval = 100 * time.Millisecond
+
+// memSampler samples Go heap and C heap in use periodically to find peaks.
+type memSampler struct {
+	stop chan struct{}
+	done chan struct{}
+
+	goHeap int64
+	// cHeap is -1 if unknown.
+	cHeap int64
+}
+
+func startMemSampler() *memSampler {
+	if !*memStats {
+		return nil
+	}
+	// Reset peak rss of process, see proc(5).
+	ioutil.WriteFile("/proc/self/clear_refs", []byte("5"), 0)
+	s := &memSampler{stop: make(chan struct{}), done: make(chan struct{}), cHeap: -1}
+	s.sample()
+	go s.run()
+	return s
+}
+
+func (s *memSampler) sample() {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	if n := int64(ms.HeapInuse); n > s.goHeap {
+		s.goHeap = n
+	}
+	// Only cgo driver allocates from C heap.
+	if *driverName != "cgo" {
+		return
+	}
+	if n, ok := cgo.HeapInuse(); ok && n > s.cHeap {
+		s.cHeap = n
+	}
+}
+
+func (s *memSampler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(memSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			s.sample()
+			return
+		case <-ticker.C:
+			s.sample()
+		}
+	}
+}
+
+// Stop stops sampling, after which peaks are final.
+func (s *memSampler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+// readPeakRSS reads peak resident set size of process from /proc/self/status.
+func readPeakRSS() (int64, bool) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "VmHWM:" && fields[2] == "kB" {
+			n, err := strconv.ParseInt(fields[1], 10, 64)
+			return n * 1024, err == nil
+		}
+	}
+	return 0, false
+}
+
+// reportMemStats reports peak memory usage of run, sampler must have been
+// stopped.
+func (run *benchmarkRun) reportMemStats() {
+	s := run.mem
+	if s == nil {
+		return
+	}
+	if n, ok := readPeakRSS(); ok {
+		run.reportMetric(float64(n), "peak-rss-B")
+	}
+	run.reportMetric(float64(s.goHeap), "peak-go-heap-B")
+	if s.cHeap >= 0 {
+		run.reportMetric(float64(s.cHeap), "peak-c-heap-B")
+	}
+}
@@ -0,0 +1,170 @@
+package leveldb_test
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+var mix = flag.String("mix", "get:80,put:15,delete:5", "Weights of get, put, delete and scan operations in mixed benchmark")
+var scanLength = flag.Int("scan_length", 100, "Number of entries each scan reads in mixed benchmark")
+
+const (
+	mixGet = iota
+	mixPut
+	mixDelete
+	mixScan
+	mixOps
+)
+
+var mixNames = [mixOps]string{"get", "put", "delete", "scan"}
+
+type mixWeights [mixOps]int
+
+func parseMix(s string) (mixWeights, error) {
+	var w mixWeights
+	var total int
+	for _, field := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(field), ":")
+		if len(parts) != 2 {
+			return w, fmt.Errorf("invalid mix %q: want op:weight", field)
+		}
+		op := -1
+		for i, name := range mixNames {
+			if name == parts[0] {
+				op = i
+			}
+		}
+		if op < 0 {
+			return w, fmt.Errorf("invalid mix %q: unknown op %q", field, parts[0])
+		}
+		weight, err := strconv.Atoi(parts[1])
+		if err != nil || weight < 0 {
+			return w, fmt.Errorf("invalid mix %q: invalid weight %q", field, parts[1])
+		}
+		w[op] += weight
+		total += weight
+	}
+	if total == 0 {
+		return w, fmt.Errorf("invalid mix %q: no weight", s)
+	}
+	return w, nil
+}
+
+// pick picks an operation with probability proportional to its weight.
+func (w *mixWeights) pick(r *rand.Rand) int {
+	var total int
+	for _, weight := range w {
+		total += weight
+	}
+	n := r.Intn(total)
+	for op, weight := range w {
+		if n < weight {
+			return op
+		}
+		n -= weight
+	}
+	panic("unreachable")
+}
+
+type mixedWorker struct {
+	db      driver.DB
+	get     *getter
+	weights *mixWeights
+	keys    keyGenerator
+	values  randomValueGenerator
+	r       *rand.Rand
+	counts  [mixOps]int
+}
+
+func (w *mixedWorker) Do(i int) error {
+	key := w.keys.Key(i)
+	op := w.weights.pick(w.r)
+	w.counts[op]++
+	switch op {
+	case mixGet:
+		_, err := w.get.Get(key)
+		if err != nil && !w.db.IsNotFound(err) {
+			return fmt.Errorf("db get key[%s] error: %s", key, err)
+		}
+	case mixPut:
+		if err := w.db.Put(key, w.values.Value(i), &writeOptions); err != nil {
+			return fmt.Errorf("db put key[%s] error: %s", key, err)
+		}
+	case mixDelete:
+		if err := w.db.Delete(key, &writeOptions); err != nil {
+			return fmt.Errorf("db delete key[%s] error: %s", key, err)
+		}
+	case mixScan:
+		it := w.db.All(&readOptions)
+		for ok, n := it.Seek(key), 0; ok && n < *scanLength; ok, n = it.Next(), n+1 {
+			iterKey(it)
+			iterValue(it)
+		}
+		if err := it.Close(); err != nil {
+			return fmt.Errorf("db scan from key[%s] error: %s", key, err)
+		}
+	}
+	return nil
+}
+
+func buildMixed(parallelism int) func(*testing.B) {
+	return func(b *testing.B) {
+		weights, err := parseMix(*mix)
+		if err != nil {
+			b.Fatal(err)
+		}
+		db, cleanup := openFullDB(b)
+		defer cleanup()
+		keys := newRoundKeyGenerator(newRandomKeyGenerator(b.N))
+		values := makeValueGenerator(newRand())
+
+		var mu sync.Mutex
+		var counts [mixOps]int
+		var workers int64
+		newOp := func() (func(int) error, func()) {
+			mu.Lock()
+			workers++
+			w := &mixedWorker{
+				db:      db,
+				get:     newGetter(db),
+				weights: &weights,
+				keys:    keys,
+				values:  values,
+				r:       rand.New(rand.NewSource(randSeed + workers)),
+			}
+			mu.Unlock()
+			return w.Do, func() {
+				mu.Lock()
+				for op, n := range w.counts {
+					counts[op] += n
+				}
+				mu.Unlock()
+			}
+		}
+		runConcurrently(b, parallelism, func(start, n, workers int) error {
+			return runOps(n, workers, func() (func(int) error, func()) {
+				op, done := newOp()
+				return func(i int) error { return op(start + i) }, done
+			})
+		})
+		seconds := b.Elapsed().Seconds()
+		for op, n := range counts {
+			if weights[op] != 0 && seconds > 0 {
+				reportMetric(b, float64(n)/seconds, mixNames[op]+"/s")
+			}
+		}
+	}
+}
+
+// BenchmarkMixed runs mixed get, put, delete and scan operations with weights
+// from -mix on a prefilled db.
+func BenchmarkMixed(b *testing.B) {
+	runParallelismSweep(b, buildMixed)
+}
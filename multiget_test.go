@@ -0,0 +1,50 @@
+package leveldb_test
+
+import (
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+var multiGetSize = flag.Int("multi_get_size", 16, "Number of keys per MultiGet in BenchmarkMultiGet")
+
+// multiGetKeys gets keys from g in [0, n) by MultiGet of -multi_get_size keys.
+// With -rate, batches are scheduled so that keys are read at -rate per second.
+func multiGetKeys(db driver.DB, n int, workers int, g keyGenerator) error {
+	size := maxInt(*multiGetSize, 1)
+	return runOpsAt(*rate/float64(size), (n+size-1)/size, workers, func() (func(int) error, func()) {
+		keys := make([][]byte, 0, size)
+		return func(i int) error {
+			keys = keys[:0]
+			for j := i * size; j < n && j < (i+1)*size; j++ {
+				keys = append(keys, g.Key(j))
+			}
+			_, errs := db.MultiGet(keys, &readOptions)
+			for j, err := range errs {
+				if err != nil {
+					return fmt.Errorf("db multi get key[%s] error: %s", keys[j], err)
+				}
+			}
+			return nil
+		}, nil
+	})
+}
+
+func buildMultiGet(parallelism int) func(*testing.B) {
+	return func(b *testing.B) {
+		db, cleanup := openFullDB(b)
+		defer cleanup()
+		g := newRoundKeyGenerator(newRandomKeyGenerator(b.N))
+		runConcurrently(b, parallelism, func(start, n, workers int) error {
+			return multiGetKeys(db, n, workers, newStartAtKeyGenerator(start, g))
+		})
+	}
+}
+
+// BenchmarkMultiGet reads random keys in batches of -multi_get_size keys,
+// ns/op is per key.
+func BenchmarkMultiGet(b *testing.B) {
+	runParallelismSweep(b, buildMultiGet)
+}
@@ -0,0 +1,147 @@
+package leveldb_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+// dataSize returns total size of table and log files in dir.
+func dataSize(dir string) (int64, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, f := range files {
+		switch filepath.Ext(f.Name()) {
+		case ".ldb", ".sst", ".log":
+			size += f.Size()
+		}
+	}
+	return size, nil
+}
+
+// settledDataSize returns total size of table and log files in dir once it
+// stays unchanged for a while, as background compactions change table files.
+func settledDataSize(dir string) (int64, error) {
+	last := int64(-1)
+	for i := 0; i < 300; i++ {
+		size, err := dataSize(dir)
+		if err != nil || size == last {
+			return size, err
+		}
+		last = size
+		time.Sleep(100 * time.Millisecond)
+	}
+	return last, nil
+}
+
+// compactedSize copies entries of src to a fresh db in order, compacts it
+// fully, and returns its settled data size. It is the least space entries of
+// src take with same options, including compression.
+func compactedSize(src driver.DB) (int64, error) {
+	db, dir := createDB("", &createOptions)
+	defer destroyDB(dir)
+	defer db.Close()
+	c, ok := db.(driver.Compacter)
+	if !ok {
+		return 0, fmt.Errorf("driver %s does not support compaction", *driverName)
+	}
+	batch := db.Batch()
+	write := func() error {
+		err := db.Write(batch, &writeOptions)
+		batch.Clear()
+		return err
+	}
+	it := src.All(&readOptions)
+	for it.Next() {
+		batch.Put(iterKey(it), iterValue(it))
+		if batch.Len() >= 1000 {
+			if err := write(); err != nil {
+				it.Close()
+				return 0, err
+			}
+		}
+	}
+	if err := it.Close(); err != nil {
+		return 0, err
+	}
+	if err := write(); err != nil {
+		return 0, err
+	}
+	// Manual compaction of kezhuw may never finish if it is requested while
+	// background compactions run, so let them settle first.
+	if _, err := settledDataSize(dir); err != nil {
+		return 0, err
+	}
+	if err := c.CompactRange(nil, nil); err != nil {
+		return 0, err
+	}
+	return settledDataSize(dir)
+}
+
+// buildOverwrite builds benchmark overwriting keys of template db, ids of
+// keys are chosen by id from r and index of write.
+func buildOverwrite(id func(r *rand.Rand, i int) int) func(*testing.B) {
+	return func(b *testing.B) {
+		if openOptions.Storage != driver.OSStorage {
+			b.Skip("overwrite benchmark requires os storage")
+		}
+		dir := cloneDB(templateDBDir)
+		defer destroyDB(dir)
+		db := openDB(dir, b)
+		defer func() { db.Close() }()
+		if _, ok := db.(driver.Compacter); !ok {
+			b.Skipf("overwrite benchmark requires compaction, which driver %s does not support", *driverName)
+		}
+		r := newRand()
+		g := &pairedEntryGenerator{
+			keyGenerator: &predefinedKeyGenerator{keys: newKeys(b.N, false, func(i int) int {
+				return id(r, i)
+			})},
+			randomValueGenerator: makeValueGenerator(r),
+		}
+		resetBenchmark(b)
+		doWrite(db, b.N, *batchCount, *rateWorkers, g)
+		b.StopTimer()
+		if seconds := b.Elapsed().Seconds(); seconds > 0 {
+			reportMetric(b, float64(b.N)/seconds, "put/s")
+		}
+		// Reopen db to remove obsolete files. Drivers may keep recent writes
+		// in log instead of flushing them to table, so logs are counted.
+		db.Close()
+		db = openDB(dir, b)
+		size, err := settledDataSize(dir)
+		if err != nil {
+			b.Fatalf("db dir %q read error: %s", dir, err)
+		}
+		compacted, err := compactedSize(db)
+		if err != nil {
+			b.Fatalf("compacted db build error: %s", err)
+		}
+		if size == 0 || compacted == 0 {
+			b.Fatalf("got data size %d of db and %d of compacted db, want both positive", size, compacted)
+		}
+		reportMetric(b, float64(size)/float64(compacted), "space-amp")
+	}
+}
+
+// BenchmarkOverwriteRandom overwrites random keys of template db, and reports
+// space amplification, size of table and log files over that of a fully
+// compacted db with same entries. It is measured after db is reopened and
+// compactions settle, manifest is not counted.
+func BenchmarkOverwriteRandom(b *testing.B) {
+	buildOverwrite(func(r *rand.Rand, i int) int { return r.Intn(templateDBEntries) })(b)
+}
+
+// BenchmarkOverwriteSequential overwrites keys of template db in order,
+// wrapping around at end of db.
+func BenchmarkOverwriteSequential(b *testing.B) {
+	buildOverwrite(func(r *rand.Rand, i int) int { return i % templateDBEntries })(b)
+}
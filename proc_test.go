@@ -0,0 +1,107 @@
+package leveldb_test
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var procStats = flag.Bool("proc_stats", false, "Report disk io, syscalls, page faults and cpu time of process per op, linux only")
+
+// procStat is a sample of /proc/self/io and /proc/self/stat.
+type procStat struct {
+	ReadBytes  int64
+	WriteBytes int64
+	Syscr      int64
+	Syscw      int64
+	Minflt     int64
+	Majflt     int64
+	// Utime and Stime are in clock ticks.
+	Utime int64
+	Stime int64
+}
+
+// clockTicks is USER_HZ, which is 100 on all architectures Go supports.
+const clockTicks = 100
+
+func readProcIO(s *procStat) error {
+	f, err := os.Open("/proc/self/io")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fields := map[string]*int64{
+		"read_bytes":  &s.ReadBytes,
+		"write_bytes": &s.WriteBytes,
+		"syscr":       &s.Syscr,
+		"syscw":       &s.Syscw,
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 || fields[parts[0]] == nil {
+			continue
+		}
+		if *fields[parts[0]], err = strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64); err != nil {
+			return fmt.Errorf("/proc/self/io: %s", err)
+		}
+	}
+	return scanner.Err()
+}
+
+func readProcStat() (procStat, error) {
+	var s procStat
+	if err := readProcIO(&s); err != nil {
+		return s, err
+	}
+	data, err := ioutil.ReadFile("/proc/self/stat")
+	if err != nil {
+		return s, err
+	}
+	// Fields after comm, which may contain spaces, starting from state.
+	fields := strings.Fields(string(data[bytes.LastIndexByte(data, ')')+1:]))
+	if len(fields) < 13 {
+		return s, fmt.Errorf("/proc/self/stat: too few fields")
+	}
+	for i, p := range map[int]*int64{7: &s.Minflt, 9: &s.Majflt, 11: &s.Utime, 12: &s.Stime} {
+		if *p, err = strconv.ParseInt(fields[i], 10, 64); err != nil {
+			return s, fmt.Errorf("/proc/self/stat: %s", err)
+		}
+	}
+	return s, nil
+}
+
+// sampleProcStat samples process statistics if -proc_stats is set and they
+// are available.
+func sampleProcStat() *procStat {
+	if !*procStats {
+		return nil
+	}
+	s, err := readProcStat()
+	if err != nil {
+		return nil
+	}
+	return &s
+}
+
+// reportProcStats reports process statistics per op since start of run.
+func (run *benchmarkRun) reportProcStats() {
+	start, end := run.proc, sampleProcStat()
+	if start == nil || end == nil {
+		return
+	}
+	n := float64(run.b.N)
+	run.reportMetric(float64(end.ReadBytes-start.ReadBytes)/n, "disk-read-B/op")
+	run.reportMetric(float64(end.WriteBytes-start.WriteBytes)/n, "disk-write-B/op")
+	run.reportMetric(float64(end.Syscr-start.Syscr)/n, "syscr/op")
+	run.reportMetric(float64(end.Syscw-start.Syscw)/n, "syscw/op")
+	run.reportMetric(float64(end.Minflt-start.Minflt)/n, "minflt/op")
+	run.reportMetric(float64(end.Majflt-start.Majflt)/n, "majflt/op")
+	run.reportMetric(float64(end.Utime-start.Utime)*1e9/clockTicks/n, "user-ns/op")
+	run.reportMetric(float64(end.Stime-start.Stime)*1e9/clockTicks/n, "sys-ns/op")
+}
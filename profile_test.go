@@ -0,0 +1,91 @@
+package leveldb_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+var profileDir = flag.String("profile_dir", "", "Write cpu, heap, mutex and block profiles of each benchmark to this directory")
+var mutexProfileFraction = flag.Int("mutex_profile_fraction", 10, "Sample 1/n of mutex contention events with -profile_dir")
+var blockProfileRate = flag.Int("block_profile_rate", 10000, "Sample one blocking event per n nanoseconds blocked with -profile_dir")
+
+func initProfiles() error {
+	if *profileDir == "" {
+		return nil
+	}
+	runtime.SetMutexProfileFraction(*mutexProfileFraction)
+	runtime.SetBlockProfileRate(*blockProfileRate)
+	return os.MkdirAll(*profileDir, 0755)
+}
+
+// profiler profiles one benchmark run. Heap, mutex and block profiles are
+// cumulative over process, so they are also written at start with suffix
+// "-base", use them as base in pprof to isolate the run:
+//
+//	go tool pprof -base kezhuw-WriteRandom.heap-base.pprof kezhuw-WriteRandom.heap.pprof
+type profiler struct {
+	b      *testing.B
+	prefix string
+	cpu    *os.File
+}
+
+var cumulativeProfiles = []string{"heap", "mutex", "block"}
+
+func startProfiler(b *testing.B) *profiler {
+	if *profileDir == "" {
+		return nil
+	}
+	name := strings.Replace(strings.TrimPrefix(benchmarkName(b), "Benchmark"), "/", "-", -1)
+	p := &profiler{b: b, prefix: filepath.Join(*profileDir, *driverName+"-"+name)}
+	for _, name := range cumulativeProfiles {
+		p.write(name, "-base")
+	}
+	f, err := os.Create(p.prefix + ".cpu.pprof")
+	if err != nil {
+		b.Errorf("cpu profile create error: %s", err)
+		return p
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		b.Errorf("cpu profile start error: %s", err)
+		return p
+	}
+	p.cpu = f
+	return p
+}
+
+func (p *profiler) write(name, suffix string) {
+	f, err := os.Create(p.prefix + "." + name + suffix + ".pprof")
+	if err != nil {
+		p.b.Errorf("%s profile create error: %s", name, err)
+		return
+	}
+	err = pprof.Lookup(name).WriteTo(f, 0)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		p.b.Errorf("%s profile write error: %s", name, err)
+	}
+}
+
+// Stop stops cpu profiling and writes other profiles.
+func (p *profiler) Stop() {
+	if p == nil {
+		return
+	}
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		if err := p.cpu.Close(); err != nil {
+			p.b.Errorf("cpu profile close error: %s", err)
+		}
+	}
+	for _, name := range cumulativeProfiles {
+		p.write(name, "")
+	}
+}
@@ -0,0 +1,79 @@
+package leveldb_test
+
+import (
+	"flag"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var rate = flag.Float64("rate", 0, "Issue operations at this rate per second from worker pools, 0 for closed loop")
+var rateWorkers = flag.Int("rate_workers", 64, "Number of workers issuing operations in -rate mode")
+
+// runOps calls op(i) for i in [0, n) and stops at first error. newOp is
+// called once per goroutine to create op and optional done which is called
+// after last op of that goroutine.
+//
+// By default, ops run one after another in calling goroutine. With -rate,
+// ops of running benchmark are issued on fixed schedule by workers, and
+// latency is measured from intended start time of each op, so stalls show
+// up in latency instead of silently lowering throughput.
+func runOps(n int, workers int, newOp func() (op func(i int) error, done func())) error {
+	return runOpsAt(*rate, n, workers, newOp)
+}
+
+// runOpsAt is runOps issuing ops at rate per second, 0 for closed loop.
+func runOpsAt(rate float64, n int, workers int, newOp func() (op func(i int) error, done func())) error {
+	if rate <= 0 || currentRun == nil {
+		op, done := newOp()
+		rec := newRecorder()
+		for i := 0; i < n; i++ {
+			t := rec.Start()
+			err := op(i)
+			rec.Done(t)
+			if err != nil {
+				return err
+			}
+		}
+		if done != nil {
+			done()
+		}
+		return nil
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	base := time.Now()
+	next := int64(-1)
+	var stopped int32
+	var once sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	wg.Add(maxInt(workers, 1))
+	for w := maxInt(workers, 1); w > 0; w-- {
+		go func() {
+			defer wg.Done()
+			op, done := newOp()
+			if done != nil {
+				defer done()
+			}
+			rec := newRecorder()
+			for atomic.LoadInt32(&stopped) == 0 {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				intended := base.Add(time.Duration(i) * interval)
+				if d := time.Until(intended); d > 0 {
+					time.Sleep(d)
+				}
+				err := op(i)
+				rec.Done(intended)
+				if err != nil {
+					once.Do(func() { firstErr = err })
+					atomic.StoreInt32(&stopped, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
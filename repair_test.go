@@ -0,0 +1,88 @@
+package leveldb_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+// corruptDB corrupts db in dir by truncating its manifest and overwriting
+// middle of its first table file with garbage. Corrupted table is rewritten
+// in place of old one, as table files of cloned db may be hard linked.
+func corruptDB(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	corrupted := false
+	for _, f := range files {
+		name := filepath.Join(dir, f.Name())
+		switch ext := filepath.Ext(f.Name()); {
+		case strings.HasPrefix(f.Name(), "MANIFEST-"):
+			if err := os.Truncate(name, f.Size()/2); err != nil {
+				return err
+			}
+		case (ext == ".ldb" || ext == ".sst") && !corrupted:
+			data, err := ioutil.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			mid := len(data) / 2
+			for i := mid; i < len(data) && i < mid+64; i++ {
+				data[i] ^= 0xff
+			}
+			if err := os.Remove(name); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(name, data, 0644); err != nil {
+				return err
+			}
+			corrupted = true
+		}
+	}
+	return nil
+}
+
+func BenchmarkRepair(b *testing.B) {
+	if openOptions.Storage != driver.OSStorage {
+		b.Skip("repair benchmark requires os storage")
+	}
+	resetBenchmark(b)
+	rec := newRecorder()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		dir := cloneDB(templateDBDir)
+		if err := corruptDB(dir); err != nil {
+			destroyDB(dir)
+			b.Fatalf("corrupt db %q error: %s", dir, err)
+		}
+		b.StartTimer()
+		t := rec.Start()
+		err := driver.Repair(*driverName, dir, &openOptions)
+		rec.Done(t)
+		b.StopTimer()
+		if err == nil {
+			var db driver.DB
+			if db, err = driver.Open(*driverName, dir, &openOptions); err == nil {
+				db.Close()
+			} else {
+				err = fmt.Errorf("open repaired db: %s", err)
+			}
+		}
+		destroyDB(dir)
+		var unsupported *driver.UnsupportedOperationError
+		switch {
+		case errors.As(err, &unsupported):
+			b.Skip(err)
+		case err != nil:
+			b.Fatalf("repair db %q error: %s", dir, err)
+		}
+		b.StartTimer()
+	}
+}
@@ -0,0 +1,148 @@
+package report
+
+import (
+	"math/bits"
+	"time"
+)
+
+const (
+	subBucketBits = 4
+	subBuckets    = 1 << subBucketBits
+)
+
+// Histogram records durations in log-linear buckets. Each power-of-two range
+// is split into 16 equal sub-buckets, so relative error is bounded by 1/16.
+type Histogram struct {
+	Counts []int64 `json:"counts"`
+	Total  int64   `json:"total"`
+	Sum    int64   `json:"sum"`
+	Min    int64   `json:"min"`
+	Max    int64   `json:"max"`
+}
+
+func bucketIndex(v int64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	s := bits.Len64(uint64(v)) - subBucketBits - 1
+	return subBuckets*s + int(v>>uint(s))
+}
+
+func bucketLower(i int) int64 {
+	if i < subBuckets {
+		return int64(i)
+	}
+	s := i/subBuckets - 1
+	return int64(i%subBuckets+subBuckets) << uint(s)
+}
+
+func bucketUpper(i int) int64 {
+	return bucketLower(i + 1)
+}
+
+// Record adds d to histogram.
+func (h *Histogram) Record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	i := bucketIndex(v)
+	if i >= len(h.Counts) {
+		counts := make([]int64, i+1)
+		copy(counts, h.Counts)
+		h.Counts = counts
+	}
+	h.Counts[i]++
+	if h.Total == 0 || v < h.Min {
+		h.Min = v
+	}
+	if v > h.Max {
+		h.Max = v
+	}
+	h.Total++
+	h.Sum += v
+}
+
+// Merge adds all samples of o to h.
+func (h *Histogram) Merge(o *Histogram) {
+	if o.Total == 0 {
+		return
+	}
+	if len(o.Counts) > len(h.Counts) {
+		counts := make([]int64, len(o.Counts))
+		copy(counts, h.Counts)
+		h.Counts = counts
+	}
+	for i, n := range o.Counts {
+		h.Counts[i] += n
+	}
+	if h.Total == 0 || o.Min < h.Min {
+		h.Min = o.Min
+	}
+	if o.Max > h.Max {
+		h.Max = o.Max
+	}
+	h.Total += o.Total
+	h.Sum += o.Sum
+}
+
+// Mean returns average of recorded durations.
+func (h *Histogram) Mean() time.Duration {
+	if h.Total == 0 {
+		return 0
+	}
+	return time.Duration(h.Sum / h.Total)
+}
+
+// Quantile returns an upper bound of q-th quantile, with q in [0, 1].
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.Total == 0 {
+		return 0
+	}
+	rank := int64(q*float64(h.Total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var n int64
+	for i, c := range h.Counts {
+		n += c
+		if n >= rank {
+			return h.clamp(bucketUpper(i) - 1)
+		}
+	}
+	return time.Duration(h.Max)
+}
+
+func (h *Histogram) clamp(v int64) time.Duration {
+	switch {
+	case v > h.Max:
+		return time.Duration(h.Max)
+	case v < h.Min:
+		return time.Duration(h.Min)
+	}
+	return time.Duration(v)
+}
+
+// Point is a point in cumulative distribution.
+type Point struct {
+	Latency  time.Duration
+	Fraction float64
+}
+
+// CDF returns cumulative distribution of recorded durations, one point
+// per non empty bucket.
+func (h *Histogram) CDF() []Point {
+	var points []Point
+	var n int64
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		n += c
+		points = append(points, Point{
+			Latency:  h.clamp(bucketUpper(i) - 1),
+			Fraction: float64(n) / float64(h.Total),
+		})
+	}
+	return points
+}
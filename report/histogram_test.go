@@ -0,0 +1,110 @@
+package report
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestBucketBoundaries(t *testing.T) {
+	tests := []struct {
+		v     int64
+		index int
+		lower int64
+	}{
+		{0, 0, 0},
+		{1, 1, 1},
+		{15, 15, 15},
+		{16, 16, 16},
+		{31, 31, 31},
+		{32, 32, 32},
+		{33, 32, 32},
+		{34, 33, 34},
+		{63, 47, 62},
+		{64, 48, 64},
+		{67, 48, 64},
+		{68, 49, 68},
+		{127, 63, 124},
+		{128, 64, 128},
+		{1 << 20, 16*16 + 16, 1 << 20},
+		{1<<20 + 1<<16 - 1, 16*16 + 16, 1 << 20},
+		{1<<20 + 1<<16, 16*16 + 17, 1<<20 + 1<<16},
+		{1<<21 - 1, 16*16 + 31, 1<<21 - 1<<16},
+	}
+	for _, test := range tests {
+		i := bucketIndex(test.v)
+		if i != test.index || bucketLower(i) != test.lower {
+			t.Errorf("bucket of %d: got index %d lower %d, want index %d lower %d", test.v, i, bucketLower(i), test.index, test.lower)
+		}
+	}
+}
+
+func TestBucketEdges(t *testing.T) {
+	for i := 0; i < 16*40; i++ {
+		lower, upper := bucketLower(i), bucketUpper(i)
+		if lower >= upper {
+			t.Fatalf("bucket %d: lower %d >= upper %d", i, lower, upper)
+		}
+		if bucketIndex(lower) != i || bucketIndex(upper-1) != i || bucketIndex(upper) != i+1 {
+			t.Fatalf("bucket %d: edges [%d, %d) map to %d, %d and %d", i, lower, upper, bucketIndex(lower), bucketIndex(upper-1), bucketIndex(upper))
+		}
+		if i >= subBuckets && (upper-lower)*subBuckets > lower {
+			t.Fatalf("bucket %d: width %d exceeds 1/16 of lower %d", i, upper-lower, lower)
+		}
+		if i%subBuckets == 0 && i >= subBuckets && lower&(lower-1) != 0 {
+			t.Fatalf("bucket %d: lower %d is not power of two", i, lower)
+		}
+	}
+}
+
+func TestQuantileUpperBound(t *testing.T) {
+	var h Histogram
+	if h.Quantile(0.5) != 0 {
+		t.Fatalf("quantile of empty histogram: got %d", h.Quantile(0.5))
+	}
+	r := rand.New(rand.NewSource(1))
+	values := make([]int64, 10000)
+	for i := range values {
+		values[i] = 1000 + r.Int63n(1000000)
+		h.Record(time.Duration(values[i]))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	for _, q := range []float64{0, 0.1, 0.5, 0.9, 0.99, 0.999, 1} {
+		rank := int(q*float64(len(values)) + 0.5)
+		if rank < 1 {
+			rank = 1
+		}
+		exact := values[rank-1]
+		got := int64(h.Quantile(q))
+		if got < exact || got > exact+exact/subBuckets {
+			t.Errorf("quantile %g: got %d, want in [%d, %d]", q, got, exact, exact+exact/subBuckets)
+		}
+	}
+	if h.Quantile(1) != time.Duration(h.Max) {
+		t.Errorf("quantile 1: got %d, want max %d", h.Quantile(1), h.Max)
+	}
+	if h.Quantile(0) < time.Duration(h.Min) {
+		t.Errorf("quantile 0: got %d, below min %d", h.Quantile(0), h.Min)
+	}
+}
+
+func TestCDFMonotonic(t *testing.T) {
+	var h Histogram
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		h.Record(time.Duration(r.ExpFloat64() * 1e6))
+	}
+	points := h.CDF()
+	if len(points) == 0 {
+		t.Fatal("empty cdf")
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].Latency <= points[i-1].Latency || points[i].Fraction <= points[i-1].Fraction {
+			t.Fatalf("cdf not increasing at %d: %v after %v", i, points[i], points[i-1])
+		}
+	}
+	if last := points[len(points)-1]; last.Fraction != 1 || last.Latency != time.Duration(h.Max) {
+		t.Fatalf("cdf ends at %v, want fraction 1 at max %d", last, h.Max)
+	}
+}
@@ -0,0 +1,376 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+var palette = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+const (
+	chartWidth   = 640
+	chartHeight  = 300
+	marginLeft   = 70
+	marginRight  = 20
+	marginTop    = 20
+	marginBottom = 45
+)
+
+type series struct {
+	name   string
+	color  string
+	xs, ys []float64
+}
+
+type axis struct {
+	min, max float64
+	log      bool
+	label    string
+	format   func(float64) string
+}
+
+func (a *axis) scale(v float64, length float64) float64 {
+	if a.log {
+		v, lo, hi := math.Log10(math.Max(v, 1)), math.Log10(math.Max(a.min, 1)), math.Log10(math.Max(a.max, 1))
+		if hi == lo {
+			return 0
+		}
+		return (v - lo) / (hi - lo) * length
+	}
+	if a.max == a.min {
+		return 0
+	}
+	return (v - a.min) / (a.max - a.min) * length
+}
+
+func (a *axis) ticks() []float64 {
+	var ticks []float64
+	if a.log {
+		lo := math.Floor(math.Log10(math.Max(a.min, 1)))
+		hi := math.Ceil(math.Log10(math.Max(a.max, 1)))
+		for e := lo; e <= hi; e++ {
+			if v := math.Pow(10, e); v >= a.min && v <= a.max {
+				ticks = append(ticks, v)
+			}
+		}
+		if len(ticks) >= 2 {
+			return ticks
+		}
+		return []float64{a.min, a.max}
+	}
+	const n = 5
+	for i := 0; i <= n; i++ {
+		ticks = append(ticks, a.min+(a.max-a.min)*float64(i)/n)
+	}
+	return ticks
+}
+
+func writeText(b *bytes.Buffer, x, y float64, anchor string, s string) {
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="%s">%s</text>`, x, y, anchor, html.EscapeString(s))
+}
+
+func lineChart(all []series, x, y axis) template.HTML {
+	plotWidth := float64(chartWidth - marginLeft - marginRight)
+	plotHeight := float64(chartHeight - marginTop - marginBottom)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg class="chart" width="%d" height="%d" viewBox="0 0 %d %d">`, chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<g transform="translate(%d,%d)">`, marginLeft, marginTop)
+	for _, v := range y.ticks() {
+		py := plotHeight - y.scale(v, plotHeight)
+		fmt.Fprintf(&b, `<line class="grid" x1="0" x2="%.1f" y1="%.1f" y2="%.1f"/>`, plotWidth, py, py)
+		writeText(&b, -6, py+4, "end", y.format(v))
+	}
+	for _, v := range x.ticks() {
+		px := x.scale(v, plotWidth)
+		fmt.Fprintf(&b, `<line class="grid" x1="%.1f" x2="%.1f" y1="0" y2="%.1f"/>`, px, px, plotHeight)
+		writeText(&b, px, plotHeight+16, "middle", x.format(v))
+	}
+	fmt.Fprintf(&b, `<rect class="frame" width="%.1f" height="%.1f"/>`, plotWidth, plotHeight)
+	writeText(&b, plotWidth/2, plotHeight+36, "middle", x.label)
+	fmt.Fprintf(&b, `<text transform="translate(%d,%.1f) rotate(-90)" text-anchor="middle">%s</text>`, -56, plotHeight/2, html.EscapeString(y.label))
+	for i, s := range all {
+		var points []string
+		for j := range s.xs {
+			px := x.scale(s.xs[j], plotWidth)
+			py := plotHeight - y.scale(s.ys[j], plotHeight)
+			points = append(points, fmt.Sprintf("%.1f,%.1f", px, py))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, s.color, strings.Join(points, " "))
+		ly := float64(14 * (i + 1))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="10" height="10" fill="%s"/>`, plotWidth-110, ly-9, s.color)
+		writeText(&b, plotWidth-96, ly, "start", s.name)
+	}
+	b.WriteString(`</g></svg>`)
+	return template.HTML(b.String())
+}
+
+type bar struct {
+	name  string
+	color string
+	value float64
+}
+
+func barChart(bars []bar, format func(float64) string) template.HTML {
+	const barHeight, gap, labelWidth, valueWidth = 20, 8, 90, 110
+	var max float64
+	for _, bar := range bars {
+		max = math.Max(max, bar.value)
+	}
+	height := len(bars)*(barHeight+gap) + gap
+	length := float64(chartWidth - labelWidth - valueWidth)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg class="chart" width="%d" height="%d" viewBox="0 0 %d %d">`, chartWidth, height, chartWidth, height)
+	for i, bar := range bars {
+		y := float64(gap + i*(barHeight+gap))
+		w := 0.0
+		if max > 0 {
+			w = bar.value / max * length
+		}
+		writeText(&b, labelWidth-8, y+barHeight*0.7, "end", bar.name)
+		fmt.Fprintf(&b, `<rect x="%d" y="%.1f" width="%.1f" height="%d" fill="%s"/>`, labelWidth, y, w, barHeight, bar.color)
+		writeText(&b, labelWidth+w+6, y+barHeight*0.7, "start", format(bar.value))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+func formatDuration(ns float64) string {
+	switch {
+	case ns >= 1e9:
+		return fmt.Sprintf("%.3gs", ns/1e9)
+	case ns >= 1e6:
+		return fmt.Sprintf("%.3gms", ns/1e6)
+	case ns >= 1e3:
+		return fmt.Sprintf("%.3gµs", ns/1e3)
+	}
+	return fmt.Sprintf("%.3gns", ns)
+}
+
+func formatNumber(v float64) string {
+	switch {
+	case math.Abs(v) >= 1e9:
+		return fmt.Sprintf("%.3gG", v/1e9)
+	case math.Abs(v) >= 1e6:
+		return fmt.Sprintf("%.3gM", v/1e6)
+	case math.Abs(v) >= 1e3:
+		return fmt.Sprintf("%.3gk", v/1e3)
+	}
+	return fmt.Sprintf("%.3g", v)
+}
+
+func formatPercent(v float64) string {
+	return fmt.Sprintf("%.0f%%", v*100)
+}
+
+func formatSeconds(v float64) string {
+	return fmt.Sprintf("%.3gs", v)
+}
+
+type quantiles struct {
+	Driver string
+	Color  string
+	Values []string
+}
+
+type workload struct {
+	Name       string
+	Bars       template.HTML
+	CDF        template.HTML
+	Throughput template.HTML
+	Quantiles  []quantiles
+}
+
+type optionRow struct {
+	Name   string
+	Values []string
+}
+
+type page struct {
+	Title     string
+	Generated string
+	Drivers   []string
+	Quantiles []string
+	Workloads []workload
+	Options   []optionRow
+}
+
+var quantilePoints = []float64{0.5, 0.9, 0.99, 0.999, 1}
+
+var pageTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Helvetica Neue", Arial, sans-serif; margin: 2em; color: #222; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: 4px; margin-top: 2em; }
+.charts { display: flex; flex-wrap: wrap; gap: 16px; }
+.chart text { font-size: 11px; fill: #333; }
+.chart .grid { stroke: #eee; }
+.chart .frame { fill: none; stroke: #999; }
+table { border-collapse: collapse; margin: 8px 0; font-size: 13px; }
+th, td { border: 1px solid #ddd; padding: 3px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.swatch { display: inline-block; width: 10px; height: 10px; margin-right: 6px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Generated at {{.Generated}} for drivers: {{range $i, $d := .Drivers}}{{if $i}}, {{end}}{{$d}}{{end}}.</p>
+{{range .Workloads}}
+<h2>{{.Name}}</h2>
+<h3>Time per operation</h3>
+{{.Bars}}
+{{if .Quantiles}}
+<table>
+<tr><th>driver</th>{{range $.Quantiles}}<th>{{.}}</th>{{end}}</tr>
+{{range .Quantiles}}<tr><td><span class="swatch" style="background: {{.Color}}"></span>{{.Driver}}</td>{{range .Values}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}
+<div class="charts">
+{{if .CDF}}<div><h3>Latency CDF</h3>{{.CDF}}</div>{{end}}
+{{if .Throughput}}<div><h3>Throughput over time</h3>{{.Throughput}}</div>{{end}}
+</div>
+{{end}}
+<h2>Options</h2>
+<table>
+<tr><th>option</th>{{range .Drivers}}<th>{{.}}</th>{{end}}</tr>
+{{range .Options}}<tr><td>{{.Name}}</td>{{range .Values}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes a self-contained html report of records to w. Only last
+// record for each pair of driver and benchmark is used.
+func WriteHTML(w io.Writer, title string, records []Record) error {
+	records = Latest(records)
+	var drivers, workloads []string
+	colors := make(map[string]string)
+	byWorkload := make(map[string][]Record)
+	for _, r := range records {
+		if _, ok := colors[r.Driver]; !ok {
+			colors[r.Driver] = palette[len(drivers)%len(palette)]
+			drivers = append(drivers, r.Driver)
+		}
+		name := r.Workload()
+		if _, ok := byWorkload[name]; !ok {
+			workloads = append(workloads, name)
+		}
+		byWorkload[name] = append(byWorkload[name], r)
+	}
+
+	p := page{
+		Title:     title,
+		Generated: time.Now().Format(time.RFC1123),
+		Drivers:   drivers,
+	}
+	for _, q := range quantilePoints {
+		if q == 1 {
+			p.Quantiles = append(p.Quantiles, "max")
+			continue
+		}
+		p.Quantiles = append(p.Quantiles, fmt.Sprintf("p%g", q*100))
+	}
+	for _, name := range workloads {
+		p.Workloads = append(p.Workloads, buildWorkload(name, byWorkload[name], colors))
+	}
+	p.Options = buildOptions(drivers, records)
+	return pageTemplate.Execute(w, &p)
+}
+
+func buildWorkload(name string, records []Record, colors map[string]string) workload {
+	wl := workload{Name: name}
+	var bars []bar
+	var cdfs, timelines []series
+	latency := axis{min: math.MaxFloat64, log: true, label: "latency", format: formatDuration}
+	fraction := axis{min: 0, max: 1, label: "fraction", format: formatPercent}
+	elapsed := axis{label: "elapsed", format: formatSeconds}
+	throughput := axis{label: "ops/s", format: formatNumber}
+	for _, r := range records {
+		color := colors[r.Driver]
+		bars = append(bars, bar{name: r.Driver, color: color, value: r.NsPerOp})
+		if h := r.Latency; h != nil && h.Total != 0 {
+			s := series{name: r.Driver, color: color}
+			for _, p := range h.CDF() {
+				s.xs = append(s.xs, float64(p.Latency))
+				s.ys = append(s.ys, p.Fraction)
+			}
+			latency.min = math.Min(latency.min, float64(h.Min))
+			latency.max = math.Max(latency.max, float64(h.Max))
+			cdfs = append(cdfs, s)
+			q := quantiles{Driver: r.Driver, Color: color}
+			for _, v := range quantilePoints {
+				q.Values = append(q.Values, formatDuration(float64(h.Quantile(v))))
+			}
+			wl.Quantiles = append(wl.Quantiles, q)
+		}
+		if t := r.Throughput; t != nil && len(t.Counts) != 0 {
+			s := series{name: r.Driver, color: color}
+			for i, rate := range t.Rates() {
+				s.xs = append(s.xs, (time.Duration(i+1) * t.Interval).Seconds())
+				s.ys = append(s.ys, rate)
+				throughput.max = math.Max(throughput.max, rate)
+			}
+			elapsed.max = math.Max(elapsed.max, s.xs[len(s.xs)-1])
+			timelines = append(timelines, s)
+		}
+	}
+	wl.Bars = barChart(bars, formatDuration)
+	if len(cdfs) != 0 {
+		wl.CDF = lineChart(cdfs, latency, fraction)
+	}
+	if len(timelines) != 0 {
+		wl.Throughput = lineChart(timelines, elapsed, throughput)
+	}
+	return wl
+}
+
+func buildOptions(drivers []string, records []Record) []optionRow {
+	values := make(map[string]map[string][]string)
+	for _, r := range records {
+		for name, value := range r.Options {
+			m := values[name]
+			if m == nil {
+				m = make(map[string][]string)
+				values[name] = m
+			}
+			if !contains(m[r.Driver], value) {
+				m[r.Driver] = append(m[r.Driver], value)
+			}
+		}
+	}
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rows := make([]optionRow, 0, len(names))
+	for _, name := range names {
+		row := optionRow{Name: name}
+		for _, driver := range drivers {
+			row.Values = append(row.Values, strings.Join(values[name][driver], ", "))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
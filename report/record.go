@@ -0,0 +1,74 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// Record is result of one benchmark for one driver.
+type Record struct {
+	Driver    string             `json:"driver"`
+	Benchmark string             `json:"benchmark"`
+	Time      time.Time          `json:"time"`
+	N         int                `json:"n"`
+	NsPerOp   float64            `json:"ns_per_op"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+	Options   map[string]string  `json:"options,omitempty"`
+
+	Latency    *Histogram `json:"latency,omitempty"`
+	Throughput *Timeline  `json:"throughput,omitempty"`
+}
+
+// Workload returns benchmark name without "Benchmark" prefix.
+func (r *Record) Workload() string {
+	return strings.TrimPrefix(r.Benchmark, "Benchmark")
+}
+
+// WriteRecords writes records to w in JSON lines.
+func WriteRecords(w io.Writer, records []Record) error {
+	enc := json.NewEncoder(w)
+	for i := range records {
+		if err := enc.Encode(&records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadRecords reads records in JSON lines from r.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var record Record
+		err := dec.Decode(&record)
+		switch {
+		case err == io.EOF:
+			return records, nil
+		case err != nil:
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// Latest keeps only last record for each pair of driver and benchmark, in
+// order of their first appearance.
+func Latest(records []Record) []Record {
+	type key struct{ driver, benchmark string }
+	indices := make(map[key]int)
+	var latest []Record
+	for _, r := range records {
+		k := key{r.Driver, r.Benchmark}
+		if i, ok := indices[k]; ok {
+			latest[i] = r
+			continue
+		}
+		indices[k] = len(latest)
+		latest = append(latest, r)
+	}
+	return latest
+}
@@ -0,0 +1,79 @@
+package report
+
+import "time"
+
+// Timeline counts completed operations in fixed intervals.
+type Timeline struct {
+	Interval time.Duration `json:"interval"`
+	Counts   []int64       `json:"counts"`
+}
+
+// Add counts one operation completed at offset from start of timeline.
+func (t *Timeline) Add(offset time.Duration) {
+	if offset < 0 {
+		offset = 0
+	}
+	i := int(offset / t.Interval)
+	for len(t.Counts) <= i {
+		t.Counts = append(t.Counts, 0)
+	}
+	t.Counts[i]++
+}
+
+// Merge adds counts from o to t. Both timelines must have same interval.
+func (t *Timeline) Merge(o *Timeline) {
+	for len(t.Counts) < len(o.Counts) {
+		t.Counts = append(t.Counts, 0)
+	}
+	for i, n := range o.Counts {
+		t.Counts[i] += n
+	}
+}
+
+// Rates returns operations per second for each interval.
+func (t *Timeline) Rates() []float64 {
+	rates := make([]float64, len(t.Counts))
+	for i, n := range t.Counts {
+		rates[i] = float64(n) / t.Interval.Seconds()
+	}
+	return rates
+}
+
+// Recorder records latency and completion time of operations. It is not
+// safe for concurrent usage, concurrent workers should use their own
+// recorders and merge them afterwards. Methods of nil Recorder do nothing,
+// so callers can disable recording without branching.
+type Recorder struct {
+	base       time.Time
+	Latency    Histogram
+	Throughput Timeline
+}
+
+// NewRecorder creates a recorder whose timeline starts from base.
+func NewRecorder(base time.Time, interval time.Duration) *Recorder {
+	return &Recorder{base: base, Throughput: Timeline{Interval: interval}}
+}
+
+// Start returns start time for an operation.
+func (r *Recorder) Start() time.Time {
+	if r == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// Done records an operation which started at start.
+func (r *Recorder) Done(start time.Time) {
+	if r == nil {
+		return
+	}
+	now := time.Now()
+	r.Latency.Record(now.Sub(start))
+	r.Throughput.Add(now.Sub(r.base))
+}
+
+// Merge merges samples from o into r.
+func (r *Recorder) Merge(o *Recorder) {
+	r.Latency.Merge(&o.Latency)
+	r.Throughput.Merge(&o.Throughput)
+}
@@ -0,0 +1,204 @@
+package leveldb_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+	"github.com/kezhuw/go-leveldb-benchmarks/report"
+)
+
+var reportFile = flag.String("report", "", "Append benchmark records to this file, see cmd/leveldb-report")
+var reportInterval = flag.Duration("report_interval", 100*time.Millisecond, "Interval of throughput timeline in report")
+
+type benchmarkRun struct {
+	b     *testing.B
+	start time.Time
+
+	mu        sync.Mutex
+	recorders []*report.Recorder
+
+	metrics map[string]float64
+
+	storage driver.StorageStats
+	proc    *procStat
+	mem     *memSampler
+	prof    *profiler
+	gc      *runtime.MemStats
+
+	batchBytes int64
+}
+
+var currentRun *benchmarkRun
+
+var reportRecords []report.Record
+
+// startRun starts measurement of benchmark b, it must be called right before
+// timing. Measurement finishes after b returns.
+func startRun(b *testing.B) {
+	run := &benchmarkRun{
+		b:       b,
+		start:   time.Now(),
+		storage: storageStats.Load(),
+		proc:    sampleProcStat(),
+		mem:     startMemSampler(),
+		gc:      sampleGCStats(),
+	}
+	run.prof = startProfiler(b)
+	currentRun = run
+	b.Cleanup(func() {
+		currentRun = nil
+		run.finish()
+	})
+}
+
+// newRecorder creates recorder for timed operations of current benchmark. It
+// returns nil if neither -report nor -rate is set or no benchmark is running.
+// Each goroutine should create its own recorder.
+func newRecorder() *report.Recorder {
+	run := currentRun
+	if run == nil || (*reportFile == "" && *rate <= 0) {
+		return nil
+	}
+	r := report.NewRecorder(run.start, *reportInterval)
+	run.mu.Lock()
+	run.recorders = append(run.recorders, r)
+	run.mu.Unlock()
+	return r
+}
+
+// reportMetric reports metric of running benchmark, and records it in report.
+func (run *benchmarkRun) reportMetric(n float64, unit string) {
+	run.b.ReportMetric(n, unit)
+	if run.metrics == nil {
+		run.metrics = make(map[string]float64)
+	}
+	run.metrics[unit] = n
+}
+
+// reportMetric reports metric of benchmark b, and records it in report if b
+// is running.
+func reportMetric(b *testing.B, n float64, unit string) {
+	if run := currentRun; run != nil && run.b == b {
+		run.reportMetric(n, unit)
+		return
+	}
+	b.ReportMetric(n, unit)
+}
+
+// countBatchBytes counts bytes of batch written in running benchmark.
+func countBatchBytes(n int) {
+	if run := currentRun; run != nil {
+		atomic.AddInt64(&run.batchBytes, int64(n))
+	}
+}
+
+// benchmarkName returns name of b, which is suffixed with GOGC value in -gogc
+// sweep.
+func benchmarkName(b *testing.B) string {
+	if currentGOGC != "" {
+		return b.Name() + "/gogc-" + currentGOGC
+	}
+	return b.Name()
+}
+
+func (run *benchmarkRun) finish() {
+	run.prof.Stop()
+	if run.mem != nil {
+		run.mem.Stop()
+	}
+	if run.b.N == 0 {
+		return
+	}
+	var merged *report.Recorder
+	if len(run.recorders) != 0 {
+		merged = report.NewRecorder(run.start, *reportInterval)
+		for _, r := range run.recorders {
+			merged.Merge(r)
+		}
+	}
+	if *rate > 0 && merged != nil {
+		h := &merged.Latency
+		run.reportMetric(float64(h.Quantile(0.5)), "p50-ns")
+		run.reportMetric(float64(h.Quantile(0.99)), "p99-ns")
+		run.reportMetric(float64(h.Quantile(0.999)), "p99.9-ns")
+		run.reportMetric(float64(h.Max), "max-ns")
+	}
+	if n := atomic.LoadInt64(&run.batchBytes); n != 0 {
+		run.reportMetric(float64(n)/float64(run.b.N), "batch-B/op")
+	}
+	run.reportStorageStats()
+	run.reportProcStats()
+	run.reportMemStats()
+	run.reportGCStats()
+	if *reportFile == "" {
+		return
+	}
+	record := report.Record{
+		Driver:    *driverName,
+		Benchmark: benchmarkName(run.b),
+		Time:      run.start,
+		N:         run.b.N,
+		NsPerOp:   float64(run.b.Elapsed().Nanoseconds()) / float64(run.b.N),
+		Metrics:   run.metrics,
+		Options:   reportOptions(),
+	}
+	if merged != nil {
+		record.Latency = &merged.Latency
+		record.Throughput = &merged.Throughput
+	}
+	for i := range reportRecords {
+		if reportRecords[i].Benchmark == record.Benchmark {
+			reportRecords[i] = record
+			return
+		}
+	}
+	reportRecords = append(reportRecords, record)
+}
+
+func reportOptions() map[string]string {
+	options := map[string]string{
+		"go":         runtime.Version(),
+		"gomaxprocs": fmt.Sprint(runtime.GOMAXPROCS(0)),
+		"platform":   runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "test.") || strings.HasPrefix(f.Name, "report") {
+			return
+		}
+		options[f.Name] = f.Value.String()
+	})
+	if currentGOGC != "" {
+		options["gogc"] = currentGOGC
+	}
+	return options
+}
+
+func initReport() error {
+	if *reportInterval <= 0 {
+		return fmt.Errorf("invalid report_interval: %s", *reportInterval)
+	}
+	return nil
+}
+
+func writeReport() error {
+	if *reportFile == "" || len(reportRecords) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(*reportFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	err = report.WriteRecords(f, reportRecords)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package leveldb_test
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+var storageName = flag.String("storage", "os", "Storage of db files for pure Go drivers: os or memory")
+var storageStatsEnabled = flag.Bool("storage_stats", false, "Count file reads, writes and syncs of db")
+
+var storageStats driver.StorageStats
+
+func initStorage() error {
+	var storage int
+	switch *storageName {
+	case "os":
+		storage = driver.OSStorage
+	case "memory":
+		if *fixtureDir != "" {
+			return fmt.Errorf("-fixture_dir requires os storage")
+		}
+		storage = driver.MemoryStorage
+	default:
+		return fmt.Errorf("unknown storage: %s", *storageName)
+	}
+	openOptions.Storage = storage
+	createOptions.Storage = storage
+	if *storageStatsEnabled {
+		openOptions.StorageStats = &storageStats
+		createOptions.StorageStats = &storageStats
+	}
+	return nil
+}
+
+// reportStorageStats reports file operations per op since start of run.
+func (run *benchmarkRun) reportStorageStats() {
+	if !*storageStatsEnabled {
+		return
+	}
+	start, stats := run.storage, storageStats.Load()
+	n := float64(run.b.N)
+	run.reportMetric(float64(stats.Reads-start.Reads)/n, "reads/op")
+	run.reportMetric(float64(stats.ReadBytes-start.ReadBytes)/n, "read-B/op")
+	run.reportMetric(float64(stats.Writes-start.Writes)/n, "writes/op")
+	run.reportMetric(float64(stats.WriteBytes-start.WriteBytes)/n, "write-B/op")
+	run.reportMetric(float64(stats.Syncs-start.Syncs)/n, "syncs/op")
+}
@@ -7,15 +7,44 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 type DB struct {
-	db *leveldb.DB
+	db   *leveldb.DB
+	stor storage.Storage
 }
 
-func convertOptions(dopts *driver.Options) *opt.Options {
+type comparer struct {
+	c *driver.Comparer
+}
+
+func (c comparer) Name() string {
+	return c.c.Name
+}
+
+func (c comparer) Compare(a, b []byte) int {
+	return c.c.Compare(a, b)
+}
+
+// Separator returns nil to use a as separator, as we know nothing about the
+// order.
+func (c comparer) Separator(dst, a, b []byte) []byte {
+	return nil
+}
+
+// Successor returns nil to use b as successor.
+func (c comparer) Successor(dst, b []byte) []byte {
+	return nil
+}
+
+func convertOptions(dopts *driver.Options) (*opt.Options, error) {
 	if dopts == nil {
-		return nil
+		return nil, nil
+	}
+	if dopts.ReuseLogs {
+		return nil, &driver.UnsupportedOptionError{Driver: "syndtr", Option: "ReuseLogs"}
 	}
 	opts := &opt.Options{
 		ErrorIfMissing:         !dopts.CreateIfMissing,
@@ -23,6 +52,15 @@ func convertOptions(dopts *driver.Options) *opt.Options {
 		BlockCacheCapacity:     dopts.BlockCacheCapacity,
 		OpenFilesCacheCapacity: dopts.MaxOpenFiles,
 		WriteBuffer:            dopts.WriteBufferSize,
+		BlockSize:              dopts.BlockSize,
+		BlockRestartInterval:   dopts.BlockRestartInterval,
+		CompactionTableSize:    dopts.MaxFileSize,
+	}
+	if dopts.ParanoidChecks {
+		opts.Strict = opt.StrictAll
+	}
+	if dopts.Comparer != nil {
+		opts.Comparer = comparer{dopts.Comparer}
 	}
 	if dopts.BloomBitsPerKey > 0 {
 		opts.Filter = filter.NewBloomFilter(dopts.BloomBitsPerKey)
@@ -33,7 +71,7 @@ func convertOptions(dopts *driver.Options) *opt.Options {
 	case driver.SnappyCompression:
 		opts.Compression = opt.SnappyCompression
 	}
-	return opts
+	return opts, nil
 }
 
 func convertReadOptions(dopts *driver.ReadOptions) *opt.ReadOptions {
@@ -65,6 +103,14 @@ func (b batch) Clear() {
 	b.Batch.Reset()
 }
 
+func (b batch) ByteSize() int {
+	return driver.BatchHeaderSize + len(b.Batch.Dump())
+}
+
+func (b batch) Replay(r driver.BatchReplay) error {
+	return b.Batch.Replay(r)
+}
+
 type wrappedIterator struct {
 	it  iterator.Iterator
 	run bool
@@ -127,6 +173,16 @@ func (db *DB) Get(key []byte, opts *driver.ReadOptions) ([]byte, error) {
 	return db.db.Get(key, convertReadOptions(opts))
 }
 
+func (db *DB) MultiGet(keys [][]byte, opts *driver.ReadOptions) ([][]byte, []error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	ropts := convertReadOptions(opts)
+	for i, key := range keys {
+		values[i], errs[i] = db.db.Get(key, ropts)
+	}
+	return values, errs
+}
+
 func (db *DB) Put(key, value []byte, opts *driver.WriteOptions) error {
 	return db.db.Put(key, value, convertWriteOptions(opts))
 }
@@ -139,8 +195,16 @@ func (db *DB) Delete(key []byte, opts *driver.WriteOptions) error {
 	return db.db.Delete(key, convertWriteOptions(opts))
 }
 
+func (db *DB) CompactRange(start, limit []byte) error {
+	return db.db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
 func (db *DB) Close() error {
-	return db.db.Close()
+	err := db.db.Close()
+	if serr := db.stor.Close(); err == nil {
+		err = serr
+	}
+	return err
 }
 
 func (db *DB) Batch() driver.Batch {
@@ -159,11 +223,45 @@ type driverType struct {
 }
 
 func (driverType) Open(dir string, opts *driver.Options) (driver.DB, error) {
-	db, err := leveldb.OpenFile(dir, convertOptions(opts))
+	options, err := convertOptions(opts)
 	if err != nil {
 		return nil, err
 	}
-	return &DB{db}, nil
+	stor, err := openStorage(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	db, err := leveldb.Open(stor, options)
+	if err != nil {
+		stor.Close()
+		return nil, err
+	}
+	return &DB{db: db, stor: stor}, nil
+}
+
+func (driverType) Destroy(dir string, opts *driver.Options) error {
+	if opts != nil && opts.Storage == driver.MemoryStorage {
+		destroyMemStorage(dir)
+		return nil
+	}
+	return driver.DestroyDir(dir)
+}
+
+func (driverType) Repair(dir string, opts *driver.Options) error {
+	options, err := convertOptions(opts)
+	if err != nil {
+		return err
+	}
+	stor, err := openStorage(dir, opts)
+	if err != nil {
+		return err
+	}
+	db, err := leveldb.Recover(stor, options)
+	if err != nil {
+		stor.Close()
+		return err
+	}
+	return (&DB{db: db, stor: stor}).Close()
 }
 
 func init() {
@@ -0,0 +1,115 @@
+package syndtr
+
+import (
+	"os"
+	"sync"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// memStorages holds dbs in memory storage by name, so they can be reopened.
+var memStorages struct {
+	sync.Mutex
+	m map[string]storage.Storage
+}
+
+func openMemStorage(dir string, create bool) storage.Storage {
+	memStorages.Lock()
+	defer memStorages.Unlock()
+	stor := memStorages.m[dir]
+	if stor == nil && create {
+		if memStorages.m == nil {
+			memStorages.m = make(map[string]storage.Storage)
+		}
+		stor = storage.NewMemStorage()
+		memStorages.m[dir] = stor
+	}
+	return stor
+}
+
+func destroyMemStorage(dir string) {
+	memStorages.Lock()
+	delete(memStorages.m, dir)
+	memStorages.Unlock()
+}
+
+// countingStorage counts file operations of underlying storage.
+type countingStorage struct {
+	storage.Storage
+	stats *driver.StorageStats
+}
+
+func (s countingStorage) Open(fd storage.FileDesc) (storage.Reader, error) {
+	r, err := s.Storage.Open(fd)
+	if err != nil {
+		return nil, err
+	}
+	return countingReader{Reader: r, stats: s.stats}, nil
+}
+
+func (s countingStorage) Create(fd storage.FileDesc) (storage.Writer, error) {
+	w, err := s.Storage.Create(fd)
+	if err != nil {
+		return nil, err
+	}
+	return countingWriter{Writer: w, stats: s.stats}, nil
+}
+
+type countingReader struct {
+	storage.Reader
+	stats *driver.StorageStats
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.stats.CountRead(n)
+	return n, err
+}
+
+func (r countingReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.Reader.ReadAt(p, off)
+	r.stats.CountRead(n)
+	return n, err
+}
+
+type countingWriter struct {
+	storage.Writer
+	stats *driver.StorageStats
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.stats.CountWrite(n)
+	return n, err
+}
+
+func (w countingWriter) Sync() error {
+	w.stats.CountSync()
+	return w.Writer.Sync()
+}
+
+// openStorage opens storage of db in dir. Storage must be closed after use.
+func openStorage(dir string, dopts *driver.Options) (storage.Storage, error) {
+	if dopts == nil {
+		return storage.OpenFile(dir, false)
+	}
+	var stor storage.Storage
+	switch dopts.Storage {
+	case driver.OSStorage:
+		var err error
+		if stor, err = storage.OpenFile(dir, false); err != nil {
+			return nil, err
+		}
+	case driver.MemoryStorage:
+		if stor = openMemStorage(dir, dopts.CreateIfMissing); stor == nil {
+			return nil, &os.PathError{Op: "open", Path: dir, Err: os.ErrNotExist}
+		}
+	default:
+		return nil, &driver.UnsupportedOptionError{Driver: "syndtr", Option: "Storage"}
+	}
+	if dopts.StorageStats != nil {
+		stor = countingStorage{Storage: stor, stats: dopts.StorageStats}
+	}
+	return stor, nil
+}
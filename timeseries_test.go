@@ -0,0 +1,78 @@
+package leveldb_test
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+)
+
+var seriesCount = flag.Int("series", 4096, "Number of series in time-series benchmark")
+var seriesWindow = flag.Int("series_window", 60, "Number of latest points each range read reads in time-series benchmark")
+var seriesReadInterval = flag.Int("series_read_interval", 100, "Number of appends between range reads in time-series benchmark, 0 for no reads")
+
+// appendSeriesKey appends key of point at timestamp ts of series id to dst.
+// Keys of series are ordered by timestamp.
+func appendSeriesKey(dst []byte, id int, ts int) []byte {
+	dst = append(dst, 's')
+	dst = appendPadded(dst, uint64(id), 8)
+	dst = append(dst, '/')
+	return appendPadded(dst, uint64(ts), decimalKeyWidth)
+}
+
+// BenchmarkTimeSeries appends points with increasing timestamps to random
+// series of -series, and reads latest -series_window points of a random series
+// every -series_read_interval appends. ns/op is per append. Keys ignore
+// -key_schema, and reads require bytewise order of keys. Batched appends are
+// written before each read, so reads see all appends.
+func BenchmarkTimeSeries(b *testing.B) {
+	if *comparerName != "" && *comparerName != "bytewise" {
+		b.Skipf("time-series benchmark requires bytewise comparer, got %s", *comparerName)
+	}
+	if *seriesCount <= 0 || *seriesWindow <= 0 || *seriesReadInterval < 0 {
+		b.Fatalf("invalid series %d, series_window %d or series_read_interval %d",
+			*seriesCount, *seriesWindow, *seriesReadInterval)
+	}
+	db, cleanup := openEmptyDB(b)
+	defer cleanup()
+	r := newRand()
+	values := makeValueGenerator(r)
+	next := make([]int, *seriesCount)
+	var key, start []byte
+	var reads, points int
+	w := newDBWriter(db, *batchCount)
+	resetBenchmark(b)
+	for i := 0; i < b.N; i++ {
+		id := r.Intn(*seriesCount)
+		key = appendSeriesKey(key[:0], id, next[id])
+		next[id]++
+		w.Put(key, values.Value(i))
+		if *seriesReadInterval == 0 || (i+1)%*seriesReadInterval != 0 {
+			continue
+		}
+		w.Done()
+		id = r.Intn(*seriesCount)
+		start = appendSeriesKey(start[:0], id, maxInt(next[id]-*seriesWindow, 0))
+		prefix := start[:len(start)-decimalKeyWidth]
+		it := db.All(&readOptions)
+		for ok, n := it.Seek(start), 0; ok && n < *seriesWindow; ok, n = it.Next(), n+1 {
+			if !bytes.HasPrefix(iterKey(it), prefix) {
+				break
+			}
+			iterValue(it)
+			points++
+		}
+		if err := it.Close(); err != nil {
+			b.Fatalf("db range read from key[%s] error: %s", start, err)
+		}
+		reads++
+	}
+	w.Done()
+	b.StopTimer()
+	if seconds := b.Elapsed().Seconds(); seconds > 0 {
+		reportMetric(b, float64(b.N)/seconds, "append/s")
+		reportMetric(b, float64(reads)/seconds, "read/s")
+	}
+	if reads != 0 {
+		reportMetric(b, float64(points)/float64(reads), "points/read")
+	}
+}
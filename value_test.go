@@ -0,0 +1,214 @@
+package leveldb_test
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var valueDist = flag.String("value_dist", "fixed", "Distribution of value sizes: fixed, uniform, normal, pareto or histogram")
+var valueSizeMin = flag.Int("value_size_min", 0, "Min value size for non fixed distribution")
+var valueSizeMax = flag.Int("value_size_max", 0, "Max value size for non fixed distribution, default to 2*value_size")
+var valueStddev = flag.Float64("value_stddev", 0, "Standard deviation of normal distribution, default to value_size/4")
+var valueParetoShape = flag.Float64("value_pareto_shape", 1.16, "Shape of pareto distribution, scale is max(value_size_min, 1)")
+var valueHistogram = flag.String("value_histogram", "", `File of "size weight" or "min-max weight" lines for histogram distribution`)
+
+// Number of value sizes sampled from distribution. Value i has size
+// valueSizes[i%len(valueSizes)].
+const valueSizesLen = 64 * 1024
+
+// valueSizes are sizes of values sampled from -value_dist, it is nil for
+// fixed size.
+var valueSizes []int
+
+// meanValueSize is average size of values.
+var meanValueSize int
+
+type sizeDistribution interface {
+	Size(r *rand.Rand) int
+}
+
+type uniformDistribution struct {
+	min, max int
+}
+
+func (d uniformDistribution) Size(r *rand.Rand) int {
+	return d.min + r.Intn(d.max-d.min+1)
+}
+
+type normalDistribution struct {
+	mean, stddev float64
+}
+
+func (d normalDistribution) Size(r *rand.Rand) int {
+	return int(math.Round(r.NormFloat64()*d.stddev + d.mean))
+}
+
+type paretoDistribution struct {
+	scale, shape float64
+}
+
+func (d paretoDistribution) Size(r *rand.Rand) int {
+	u := 1 - r.Float64()
+	// Clamp before conversion, sizes of u near 0 overflow int.
+	return int(math.Min(d.scale/math.Pow(u, 1/d.shape), math.MaxInt32))
+}
+
+type histogramBucket struct {
+	min, max int
+	weight   float64
+}
+
+type histogramDistribution struct {
+	buckets []histogramBucket
+	// cumulative weights of buckets
+	weights []float64
+}
+
+func (d *histogramDistribution) Size(r *rand.Rand) int {
+	w := r.Float64() * d.weights[len(d.weights)-1]
+	i := sort.SearchFloat64s(d.weights, w)
+	if i == len(d.buckets) {
+		i--
+	}
+	b := d.buckets[i]
+	return b.min + r.Intn(b.max-b.min+1)
+}
+
+func parseHistogramBucket(line string) (histogramBucket, error) {
+	var b histogramBucket
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return b, fmt.Errorf("want two fields")
+	}
+	sizes := strings.SplitN(fields[0], "-", 2)
+	var err error
+	if b.min, err = strconv.Atoi(sizes[0]); err != nil {
+		return b, err
+	}
+	b.max = b.min
+	if len(sizes) == 2 {
+		if b.max, err = strconv.Atoi(sizes[1]); err != nil {
+			return b, err
+		}
+	}
+	if b.min < 0 || b.max < b.min {
+		return b, fmt.Errorf("invalid size range %q", fields[0])
+	}
+	if b.weight, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return b, err
+	}
+	if b.weight < 0 {
+		return b, fmt.Errorf("negative weight %q", fields[1])
+	}
+	return b, nil
+}
+
+func loadHistogramDistribution(name string) (*histogramDistribution, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var d histogramDistribution
+	var total float64
+	scanner := bufio.NewScanner(f)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		b, err := parseHistogramBucket(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", name, lineno, err)
+		}
+		total += b.weight
+		d.buckets = append(d.buckets, b)
+		d.weights = append(d.weights, total)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("%s: no weight", name)
+	}
+	return &d, nil
+}
+
+func newSizeDistribution() (sizeDistribution, error) {
+	switch *valueDist {
+	case "fixed":
+		return nil, nil
+	case "uniform":
+		return uniformDistribution{min: *valueSizeMin, max: maxValueSizeFlag()}, nil
+	case "normal":
+		stddev := *valueStddev
+		if stddev <= 0 {
+			stddev = float64(*valueSize) / 4
+		}
+		return normalDistribution{mean: float64(*valueSize), stddev: stddev}, nil
+	case "pareto":
+		if *valueParetoShape <= 0 {
+			return nil, fmt.Errorf("invalid pareto shape: %g", *valueParetoShape)
+		}
+		return paretoDistribution{scale: float64(maxInt(*valueSizeMin, 1)), shape: *valueParetoShape}, nil
+	case "histogram":
+		if *valueHistogram == "" {
+			return nil, fmt.Errorf("histogram distribution requires -value_histogram")
+		}
+		return loadHistogramDistribution(*valueHistogram)
+	}
+	return nil, fmt.Errorf("unknown value distribution: %s", *valueDist)
+}
+
+func maxValueSizeFlag() int {
+	if *valueSizeMax > 0 {
+		return *valueSizeMax
+	}
+	return 2 * *valueSize
+}
+
+func initValueSizes() error {
+	meanValueSize = *valueSize
+	d, err := newSizeDistribution()
+	if d == nil || err != nil {
+		return err
+	}
+	min, max := *valueSizeMin, maxValueSizeFlag()
+	if _, ok := d.(*histogramDistribution); ok {
+		min, max = 0, math.MaxInt32
+	}
+	if min > max {
+		return fmt.Errorf("value_size_min %d is greater than value_size_max %d", min, max)
+	}
+	r := newRand()
+	total := 0
+	valueSizes = make([]int, valueSizesLen)
+	for i := range valueSizes {
+		n := d.Size(r)
+		switch {
+		case n < min:
+			n = min
+		case n > max:
+			n = max
+		}
+		valueSizes[i] = n
+		total += n
+	}
+	meanValueSize = maxInt(total/len(valueSizes), 1)
+	return nil
+}
+
+// valueSizesName describes value sizes for fixture naming.
+func valueSizesName() string {
+	if valueSizes == nil {
+		return fmt.Sprintf("fixed:%d", *valueSize)
+	}
+	return fmt.Sprintf("%s:%d:%d:%d:%g:%g:%s", *valueDist, *valueSize, *valueSizeMin, maxValueSizeFlag(), *valueStddev, *valueParetoShape, *valueHistogram)
+}
@@ -0,0 +1,52 @@
+package leveldb_test
+
+import (
+	"flag"
+
+	"github.com/kezhuw/go-leveldb-benchmarks/driver"
+)
+
+var zeroCopy = flag.Bool("zero_copy", false, "Read values into reused buffers, and access iterators without copying if driver supports")
+
+// getter gets values from db. With -zero_copy, values are copied into a reused
+// buffer if db supports it, so value is valid only until next Get.
+type getter struct {
+	db   driver.DB
+	into driver.IntoGetter
+	buf  []byte
+}
+
+func newGetter(db driver.DB) *getter {
+	g := &getter{db: db}
+	if *zeroCopy {
+		g.into, _ = db.(driver.IntoGetter)
+	}
+	return g
+}
+
+func (g *getter) Get(key []byte) ([]byte, error) {
+	if g.into == nil {
+		return g.db.Get(key, &readOptions)
+	}
+	var err error
+	g.buf, err = g.into.GetInto(g.buf[:0], key, &readOptions)
+	return g.buf, err
+}
+
+// iterKey returns key of it, without copying if -zero_copy is set and it
+// supports.
+func iterKey(it driver.Iterator) []byte {
+	if u, ok := it.(driver.UnsafeIterator); ok && *zeroCopy {
+		return u.KeyUnsafe()
+	}
+	return it.Key()
+}
+
+// iterValue returns value of it, without copying if -zero_copy is set and it
+// supports.
+func iterValue(it driver.Iterator) []byte {
+	if u, ok := it.(driver.UnsafeIterator); ok && *zeroCopy {
+		return u.ValueUnsafe()
+	}
+	return it.Value()
+}